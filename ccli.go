@@ -1,9 +1,17 @@
 package ccli
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/rizaldihuzein/ccli/src"
 )
@@ -11,74 +19,225 @@ import (
 const (
 	errorMSG      = "sorry we encountered error \n"
 	errorPanicMSG = "sorry we encountered panic \n"
+
+	defaultPath = "data.csv"
 )
 
-func panicWrapper(f func()) {
+// command is a single ccli subcommand, run receives the arguments following
+// the subcommand name and returns the error ending ccli, see exitCode.
+type command struct {
+	name  string
+	short string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{
+		name:  "fetch",
+		short: "fetch users from the sources and replace the stored CSV",
+		run:   runFetch,
+	},
+	{
+		name:  "search",
+		short: "search the stored CSV by tags",
+		run:   runSearch,
+	},
+	{
+		name:  "stats",
+		short: "summarize the stored CSV",
+		run:   runStats,
+	},
+	{
+		name:  "validate",
+		short: "check every row of the stored CSV",
+		run:   runValidate,
+	},
+	{
+		name:  "migrate",
+		short: "rewrite an older stored CSV in the current schema",
+		run:   runMigrate,
+	},
+	{
+		name:  "diff",
+		short: "compare two snapshots of the stored data",
+		run:   runDiff,
+	},
+	{
+		name:  "serve",
+		short: "serve the stored data as a JSON API",
+		run:   runServe,
+	},
+	{
+		name:  "daemon",
+		short: "refresh the stored data on a schedule",
+		run:   runDaemon,
+	},
+}
+
+// panicWrapper turns a panic of f into an error ending ccli with exitFailure.
+func panicWrapper(f func() error) (err error) {
 	if f == nil {
-		return
+		return nil
 	}
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Println(errorPanicMSG, r)
+			err = &exitError{code: exitFailure, kind: "panic", err: fmt.Errorf("%v", r)}
 		}
 	}()
 
-	f()
+	return f()
 }
 
+// ProcessCommand runs the command named by the process arguments and exits
+// the process with its exit code, see Run.
 func ProcessCommand() {
-	panicWrapper(processCommand)
+	os.Exit(Run(os.Args[1:]))
 }
 
-func processCommand1() {
-	src.Build()
-	data, err := src.GetFromSource()
-	if err != nil {
-		fmt.Println(errorMSG, err)
-		return
+// Run runs the command named by args[0] with the remaining arguments and
+// returns the exit code of ccli: 0 on success, 1 on any other failure, 2 for
+// a bad command line, 3 when the sources could not be fetched, 4 when a data
+// file could not be read or written, 5 when a search matched no user and 130
+// when interrupted. Errors are printed on stderr, as JSON objects when the
+// command is given -json-errors.
+func Run(args []string) int {
+	err := panicWrapper(func() error {
+		return processCommand(args)
+	})
+	code, _ := exitCode(err)
+	if code != exitOK {
+		reportError(os.Stderr, err, jsonErrorsRequested(args))
+	}
+	return code
+}
+
+func processCommand(args []string) error {
+	if len(args) == 0 {
+		usage(os.Stderr)
+		return usageError(errors.New("missing command"))
 	}
 
-	var csvPath string
-	if csvPath == "" {
-		csvPath = "data.csv"
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		usage(os.Stdout)
+		return nil
 	}
 
-	err = src.SetAndReplaceToCSV(data, csvPath)
-	if err != nil {
-		fmt.Println(errorMSG, err)
-		return
+	for _, v := range commands {
+		if v.name == args[0] {
+			return v.run(args[1:])
+		}
 	}
+
+	usage(os.Stderr)
+	return usageError(fmt.Errorf("unknown command %q", args[0]))
 }
 
-func processCommand2(tags []string) {
-	src.Build()
-	data, err := src.SearchFromCSV(tags, "data.csv")
-	if err == src.ErrMissingFile {
-		fmt.Println("CSV file not found, generating new one...")
-		processCommand1()
-		data, err = src.SearchFromCSV(tags, "data.csv")
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: ccli <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, v := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", v.name, v.short)
 	}
-	if err != nil {
-		fmt.Println(errorMSG, err)
-		return
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'ccli <command> -h' for the flags of a command.")
+}
+
+// newFlagSet returns a flag set for a subcommand with a usage text describing
+// it, and the -json-errors flag every command accepts. Parse errors are left
+// to parseFlags.
+func newFlagSet(name, description string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	// Run looks -json-errors up itself, see jsonErrorsRequested.
+	fs.Bool("json-errors", false, "print errors on stderr as JSON objects with the error, its kind and the exit code")
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: ccli %s [flags]\n\n%s\n\nFlags:\n", name, description)
+		fs.PrintDefaults()
 	}
-	for _, v := range data {
-		fmt.Printf("ID: %s, Balance: %s\n", v.ID, v.Balance)
+	return fs
+}
+
+// registerStore adds the -store flag selecting the format of the data file.
+func registerStore(fs *flag.FlagSet, store *string) {
+	fs.StringVar(store, "store", "", "storage format, one of "+strings.Join(src.StoreFormats, ", ")+", picked from the -path extension when empty")
+}
+
+// registerTimeout adds the -timeout flag bounding a whole command.
+func registerTimeout(fs *flag.FlagSet, timeout *time.Duration) {
+	fs.DurationVar(timeout, "timeout", 0, "give up after this long, e.g. 30s or 2m, 0 waits until done")
+}
+
+// commandContext returns the context of a command, cancelled by SIGINT or
+// SIGTERM and once timeout elapses when it is positive. A second signal
+// kills the process as usual.
+func commandContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	if timeout <= 0 {
+		return ctx, stop
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// build returns a client for commands that never fetch.
+func build(store string) (*src.Client, error) {
+	return src.NewClient(src.ClientOptions{
+		Retry: src.DefaultRetryPolicy(),
+		Store: store,
+	})
+}
+
+// stringList collects every value of a repeated flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// splitList splits a comma separated flag value, dropping empty items.
+func splitList(s string) []string {
+	list := []string{}
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		list = append(list, v)
 	}
+	return list
 }
 
-func processCommand() {
-	var tagStr = flag.String("tag", "-1", "tags to search separated by comma")
-	flag.Parse()
-	if tagStr == nil || *tagStr == "-1" {
-		processCommand1()
-		fmt.Println("No tags found.\nGenerating CSV instead...\nTo search data, please use -tag flag\n e.g. -tag=sed,quis")
+func joinCodes(codes []int) string {
+	strs := make([]string, 0, len(codes))
+	for _, v := range codes {
+		strs = append(strs, strconv.Itoa(v))
 	}
-	if tagStr != nil {
-		tags := strings.Split(*tagStr, ",")
-		if *tagStr == "" {
-			tags = []string{}
+	return strings.Join(strs, ",")
+}
+
+func parseCodes(s string) ([]int, error) {
+	codes := []int{}
+	for _, v := range splitList(s) {
+		code, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid response code %q", v)
 		}
-		processCommand2(tags)
+		codes = append(codes, code)
 	}
+	return codes, nil
 }
@@ -0,0 +1,100 @@
+package ccli
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rizaldihuzein/ccli/src"
+)
+
+func runDaemon(args []string) error {
+	var (
+		fs        = newFlagSet("daemon", "Fetch from the sources on a schedule and replace the data file, until SIGINT or SIGTERM.\nA failed fetch is logged and the previous data is kept.\n\nThe schedule is an interval such as 15m or \"@every 1h\", one of @hourly, @daily,\n@weekly and @monthly, or a cron expression \"minute hour day-of-month month day-of-week\"\nsuch as \"*/15 * * * *\" or \"0 6 * * 1-5\", in local time.")
+		schedule  = fs.String("schedule", "15m", "when to fetch, an interval or a cron expression")
+		path      = fs.String("path", defaultPath, "data file to write")
+		immediate = fs.Bool("immediate", true, "fetch once on start instead of waiting for the first scheduled run")
+		addr      = fs.String("addr", "", "also serve the data file as a JSON API on this address, see 'ccli serve'")
+		flags     fetchFlags
+	)
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	sched, err := src.ParseSchedule(*schedule)
+	if err != nil {
+		return usageError(err)
+	}
+	opt, err := flags.build()
+	if err != nil {
+		return usageError(err)
+	}
+
+	// SIGINT or SIGTERM cancels the fetch in flight and stops the daemon.
+	ctx, stop := commandContext(0)
+	defer stop()
+
+	// Scheduled fetches and POST /refresh take turns writing the data file.
+	var refreshing sync.Mutex
+	refresh := func(ctx context.Context) error {
+		refreshing.Lock()
+		defer refreshing.Unlock()
+		return fetchAndStore(ctx, opt, *path)
+	}
+
+	var (
+		server   *http.Server
+		serveErr error
+	)
+	served := make(chan struct{})
+	if *addr != "" {
+		server = &http.Server{
+			Addr: *addr,
+			Handler: opt.client.Handler(src.ServerOptions{
+				Path:    *path,
+				Refresh: refresh,
+			}),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			defer close(served)
+			log.Printf("Serving %s on %s", *path, *addr)
+			if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				serveErr = err
+				stop()
+			}
+		}()
+	}
+
+	err = opt.client.RunDaemon(ctx, src.DaemonOptions{
+		Path:      *path,
+		Schedule:  sched,
+		Refresh:   refresh,
+		Immediate: *immediate,
+	})
+	stop()
+
+	if server != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Shutdown: %v", err)
+		}
+		<-served
+	}
+	if errors.Is(err, src.ErrInvalidSchedule) {
+		return usageError(err)
+	}
+	if err != nil {
+		return err
+	}
+	if serveErr != nil {
+		return serveErr
+	}
+	log.Printf("Stopped refreshing %s", *path)
+	return nil
+}
@@ -0,0 +1,74 @@
+package ccli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rizaldihuzein/ccli/src"
+)
+
+func runDiff(args []string) error {
+	var (
+		fs      = newFlagSet("diff", "Report the users added, removed and modified between two snapshots of the data file.\nSnapshots are referred to by a negative number counting back from the latest\n(-1), by a unique prefix of their name such as 20240131T0930, or by file path.")
+		path    = fs.String("path", defaultPath, "data file whose snapshots are compared")
+		from    = fs.String("from", "-2", "older snapshot")
+		to      = fs.String("to", "-1", "newer snapshot")
+		output  = fs.String("output", src.FormatText, "output format: "+src.FormatText+" or "+src.FormatJSON)
+		list    = fs.Bool("list", false, "list the snapshots instead of comparing them")
+		timeout time.Duration
+		store   string
+	)
+	registerStore(fs, &store)
+	registerTimeout(fs, &timeout)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext(timeout)
+	defer cancel()
+
+	client, err := build(store)
+	if err != nil {
+		return usageError(err)
+	}
+	snapshots, err := client.Snapshots(ctx, *path)
+	if err != nil {
+		return storageError(err)
+	}
+
+	if *list {
+		for i, v := range snapshots {
+			fmt.Printf("%4d  %s  %s\n", i-len(snapshots), v.Time.Format("2006-01-02 15:04:05.000Z07:00"), v.Path)
+		}
+		return nil
+	}
+
+	fromPath, err := snapshotPath(snapshots, *from)
+	if err != nil {
+		return usageError(err)
+	}
+	toPath, err := snapshotPath(snapshots, *to)
+	if err != nil {
+		return usageError(err)
+	}
+
+	diff, err := client.Diff(ctx, fromPath, toPath)
+	if err != nil {
+		return storageError(err)
+	}
+	return src.WriteDiff(os.Stdout, diff, *output)
+}
+
+// snapshotPath resolves ref to a snapshot of list, or to an existing file so
+// that any data file can be compared.
+func snapshotPath(list []src.Snapshot, ref string) (string, error) {
+	snapshot, err := src.FindSnapshot(list, ref)
+	if err == nil {
+		return snapshot.Path, nil
+	}
+	if _, statErr := os.Stat(ref); statErr == nil {
+		return ref, nil
+	}
+	return "", err
+}
@@ -0,0 +1,188 @@
+package ccli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rizaldihuzein/ccli/src"
+)
+
+// Exit codes of ccli, listed in the readme.
+const (
+	exitOK          = 0
+	exitFailure     = 1
+	exitUsage       = 2
+	exitNetwork     = 3
+	exitStorage     = 4
+	exitNoResults   = 5
+	exitInterrupted = 130
+)
+
+// exitError is an error ending ccli with code. kind names the code in the
+// JSON error objects.
+type exitError struct {
+	code int
+	kind string
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// errNoResults ends a search matching no user.
+var errNoResults = &exitError{code: exitNoResults, kind: "no_results", err: errors.New("no users matched")}
+
+// usageError marks err as caused by the command line.
+func usageError(err error) error {
+	return withCode(err, exitUsage, "usage")
+}
+
+// networkError marks err as a failure to fetch from the sources.
+func networkError(err error) error {
+	return withCode(err, exitNetwork, "network")
+}
+
+// storageError marks err as a failure to read or write a data file.
+func storageError(err error) error {
+	return withCode(err, exitStorage, "storage")
+}
+
+// streamError classifies the error of a fetch streamed into the data file:
+// errors of the sources and an expired -timeout, which mostly runs out
+// waiting for them, are network errors, any other error comes from writing
+// the file.
+func streamError(err error) error {
+	var fetchErr *src.FetchError
+	switch {
+	case errors.As(err, &fetchErr),
+		errors.Is(err, src.ErrSourcesDown),
+		errors.Is(err, src.ErrNoSources),
+		errors.Is(err, context.DeadlineExceeded):
+		return networkError(err)
+	}
+	return storageError(err)
+}
+
+// withCode wraps err with an exit code unless it already has one. Errors of
+// an interrupted command always end it as interrupted.
+func withCode(err error, code int, kind string) error {
+	if err == nil {
+		return nil
+	}
+	var e *exitError
+	if errors.As(err, &e) {
+		return err
+	}
+	if errors.Is(err, context.Canceled) {
+		code, kind = exitInterrupted, "interrupted"
+	}
+	return &exitError{code: code, kind: kind, err: err}
+}
+
+// exitCode returns the code ccli exits with after err.
+func exitCode(err error) (code int, kind string) {
+	var e *exitError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK, ""
+	case errors.As(err, &e):
+		return e.code, e.kind
+	case errors.Is(err, context.Canceled):
+		return exitInterrupted, "interrupted"
+	}
+	return exitFailure, "failure"
+}
+
+// errorObject is the JSON form of an error printed with -json-errors. The
+// source of a fetch error and the position of a malformed row are added when
+// known.
+type errorObject struct {
+	Error  string `json:"error"`
+	Kind   string `json:"kind"`
+	Code   int    `json:"code"`
+	URL    string `json:"url,omitempty"`
+	Status int    `json:"status,omitempty"`
+	Row    int    `json:"row,omitempty"`
+	Column string `json:"column,omitempty"`
+}
+
+func newErrorObject(err error) errorObject {
+	code, kind := exitCode(err)
+	obj := errorObject{Error: err.Error(), Kind: kind, Code: code}
+	var (
+		fetchErr *src.FetchError
+		parseErr *src.ParseError
+	)
+	if errors.As(err, &fetchErr) {
+		obj.URL, obj.Status = fetchErr.URL, fetchErr.StatusCode
+	}
+	if errors.As(err, &parseErr) {
+		obj.Row, obj.Column = parseErr.Row, parseErr.Column
+	}
+	return obj
+}
+
+// reportError writes err to w, as a JSON object on a single line when
+// asJSON is set.
+func reportError(w io.Writer, err error, asJSON bool) {
+	if asJSON {
+		json.NewEncoder(w).Encode(newErrorObject(err))
+		return
+	}
+	var e *exitError
+	if errors.As(err, &e) && e.kind == "panic" {
+		fmt.Fprintln(w, errorPanicMSG, e.err)
+		return
+	}
+	fmt.Fprintln(w, errorMSG, err)
+}
+
+// jsonErrorsRequested reports whether args set -json-errors. It looks ahead
+// of flag parsing so that bad flags are reported in JSON as well.
+func jsonErrorsRequested(args []string) bool {
+	for _, v := range args {
+		if v == "--" {
+			break
+		}
+		if !strings.HasPrefix(v, "-") {
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(v, "-"), "=")
+		if name != "json-errors" {
+			continue
+		}
+		if !hasValue {
+			return true
+		}
+		on, err := strconv.ParseBool(value)
+		return err == nil && on
+	}
+	return false
+}
+
+// parseFlags parses the flags of a command. -h prints the usage and ends
+// the command without error, bad flags end it with a usage error.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, flag.ErrHelp):
+		fs.SetOutput(os.Stdout)
+		fs.Usage()
+		return err
+	}
+	return usageError(fmt.Errorf("%w, run 'ccli %s -h' for usage", err, fs.Name()))
+}
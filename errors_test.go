@@ -0,0 +1,412 @@
+package ccli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/rizaldihuzein/ccli/src"
+)
+
+func Test_exitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+		wantKind string
+	}{
+		{
+			name:     "test1_nil",
+			err:      nil,
+			wantCode: exitOK,
+		},
+		{
+			name:     "test2_help",
+			err:      flag.ErrHelp,
+			wantCode: exitOK,
+		},
+		{
+			name:     "test3_unclassified",
+			err:      errors.New("err"),
+			wantCode: exitFailure,
+			wantKind: "failure",
+		},
+		{
+			name:     "test4_usage",
+			err:      usageError(errors.New("err")),
+			wantCode: exitUsage,
+			wantKind: "usage",
+		},
+		{
+			name:     "test5_network",
+			err:      networkError(errors.New("err")),
+			wantCode: exitNetwork,
+			wantKind: "network",
+		},
+		{
+			name:     "test6_storage",
+			err:      storageError(errors.New("err")),
+			wantCode: exitStorage,
+			wantKind: "storage",
+		},
+		{
+			name:     "test7_no_results",
+			err:      errNoResults,
+			wantCode: exitNoResults,
+			wantKind: "no_results",
+		},
+		{
+			name:     "test8_interrupted",
+			err:      networkError(fmt.Errorf("fetch: %w", context.Canceled)),
+			wantCode: exitInterrupted,
+			wantKind: "interrupted",
+		},
+		{
+			name:     "test9_unclassified_interrupted",
+			err:      context.Canceled,
+			wantCode: exitInterrupted,
+			wantKind: "interrupted",
+		},
+		{
+			name:     "test10_first_class_kept",
+			err:      usageError(fmt.Errorf("wrapped: %w", networkError(errors.New("err")))),
+			wantCode: exitNetwork,
+			wantKind: "network",
+		},
+		{
+			name:     "test11_panic",
+			err:      panicWrapper(func() error { panic("boom") }),
+			wantCode: exitFailure,
+			wantKind: "panic",
+		},
+		{
+			name:     "test12_stream_fetch",
+			err:      streamError(&src.FetchError{URL: "http://ccli.test", StatusCode: http.StatusNotFound, Err: src.ErrUnexpectedStatus}),
+			wantCode: exitNetwork,
+			wantKind: "network",
+		},
+		{
+			name:     "test13_stream_sources_down",
+			err:      streamError(&src.SourcesError{}),
+			wantCode: exitNetwork,
+			wantKind: "network",
+		},
+		{
+			name:     "test14_stream_timeout",
+			err:      streamError(context.DeadlineExceeded),
+			wantCode: exitNetwork,
+			wantKind: "network",
+		},
+		{
+			name:     "test15_stream_write",
+			err:      streamError(&os.PathError{Op: "rename", Path: "data.csv", Err: os.ErrPermission}),
+			wantCode: exitStorage,
+			wantKind: "storage",
+		},
+		{
+			name:     "test16_stream_backend",
+			err:      streamError(errors.New("database is locked")),
+			wantCode: exitStorage,
+			wantKind: "storage",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotCode, gotKind := exitCode(tt.err)
+			if gotCode != tt.wantCode || gotKind != tt.wantKind {
+				t.Errorf("exitCode() = %v, %q, want %v, %q", gotCode, gotKind, tt.wantCode, tt.wantKind)
+			}
+		})
+	}
+}
+
+func Test_reportError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		asJSON   bool
+		wantText string
+		wantJSON errorObject
+	}{
+		{
+			name:     "test1_text",
+			err:      storageError(errors.New("missing file")),
+			wantText: errorMSG + " missing file\n",
+		},
+		{
+			name:     "test2_text_panic",
+			err:      panicWrapper(func() error { panic("boom") }),
+			wantText: errorPanicMSG + " boom\n",
+		},
+		{
+			name:     "test3_json",
+			err:      usageError(errors.New("missing command")),
+			asJSON:   true,
+			wantJSON: errorObject{Error: "missing command", Kind: "usage", Code: exitUsage},
+		},
+		{
+			name:   "test4_json_fetch",
+			err:    networkError(&src.FetchError{URL: "http://ccli.test", StatusCode: http.StatusNotFound, Err: src.ErrUnexpectedStatus}),
+			asJSON: true,
+			wantJSON: errorObject{
+				Error:  "http://ccli.test: unexpected response code 404",
+				Kind:   "network",
+				Code:   exitNetwork,
+				URL:    "http://ccli.test",
+				Status: http.StatusNotFound,
+			},
+		},
+		{
+			name:   "test5_json_parse",
+			err:    storageError(&src.ParseError{Row: 2, Column: "active", Err: errors.New("invalid active status \"yes\"")}),
+			asJSON: true,
+			wantJSON: errorObject{
+				Error:  "row 2: invalid active status \"yes\"",
+				Kind:   "storage",
+				Code:   exitStorage,
+				Row:    2,
+				Column: "active",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			reportError(&buf, tt.err, tt.asJSON)
+			if !tt.asJSON {
+				if got := buf.String(); got != tt.wantText {
+					t.Errorf("reportError() = %q, want %q", got, tt.wantText)
+				}
+				return
+			}
+
+			if bytes.Count(buf.Bytes(), []byte("\n")) != 1 {
+				t.Errorf("reportError() = %q, want a single line", buf.String())
+			}
+			var got errorObject
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("reportError() = %q, not JSON: %v", buf.String(), err)
+			}
+			if got != tt.wantJSON {
+				t.Errorf("reportError() = %+v, want %+v", got, tt.wantJSON)
+			}
+		})
+	}
+}
+
+func Test_jsonErrorsRequested(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want bool
+	}{
+		{
+			name: "test1_absent",
+			args: []string{"fetch", "-path", "a.csv"},
+		},
+		{
+			name: "test2_first",
+			args: []string{"fetch", "-json-errors", "-path", "a.csv"},
+			want: true,
+		},
+		{
+			name: "test3_last_double_dash",
+			args: []string{"search", "-tag=a", "--json-errors"},
+			want: true,
+		},
+		{
+			name: "test4_value",
+			args: []string{"search", "-json-errors=true"},
+			want: true,
+		},
+		{
+			name: "test5_false",
+			args: []string{"search", "-json-errors=false"},
+		},
+		{
+			name: "test6_bad_value",
+			args: []string{"search", "-json-errors=maybe"},
+		},
+		{
+			name: "test7_after_terminator",
+			args: []string{"search", "--", "-json-errors"},
+		},
+		{
+			name: "test8_before_bad_flag",
+			args: []string{"fetch", "-json-errors", "-nope"},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jsonErrorsRequested(tt.args); got != tt.want {
+				t.Errorf("jsonErrorsRequested() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantPath string
+		wantCode int
+	}{
+		{
+			name:     "test1_json_errors_last",
+			args:     []string{"-path", "a.csv", "-json-errors"},
+			wantPath: "a.csv",
+		},
+		{
+			name:     "test2_json_errors_first",
+			args:     []string{"-json-errors", "-path=a.csv"},
+			wantPath: "a.csv",
+		},
+		{
+			name:     "test3_help",
+			args:     []string{"-path", "a.csv", "-h"},
+			wantPath: "a.csv",
+		},
+		{
+			name:     "test4_unknown_flag",
+			args:     []string{"-json-errors", "-nope"},
+			wantPath: defaultPath,
+			wantCode: exitUsage,
+		},
+		{
+			name:     "test5_missing_value",
+			args:     []string{"-path"},
+			wantPath: defaultPath,
+			wantCode: exitUsage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFlagSet("test", "Test command.")
+			path := fs.String("path", defaultPath, "data file")
+			err := parseFlags(fs, tt.args)
+			if code, _ := exitCode(err); code != tt.wantCode {
+				t.Errorf("parseFlags() error = %v, exit code %v, want %v", err, code, tt.wantCode)
+			}
+			if *path != tt.wantPath {
+				t.Errorf("parseFlags() -path = %q, want %q", *path, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://ccli.test/users", httpmock.NewStringResponder(http.StatusOK, `[{"_id": "1", "isActive": true, "balance": "$1,000.00", "tags": ["a"]}]`))
+	httpmock.RegisterResponder("GET", "http://ccli.test/missing", httpmock.NewStringResponder(http.StatusNotFound, ""))
+	httpmock.RegisterResponder("GET", "http://ccli.test/hang", func(req *http.Request) (*http.Response, error) {
+		// The command sees a Ctrl+C while waiting for the source.
+		p, err := os.FindProcess(os.Getpid())
+		if err != nil {
+			return nil, err
+		}
+		if err := p.Signal(os.Interrupt); err != nil {
+			return nil, err
+		}
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})
+
+	dir := t.TempDir()
+	data := filepath.Join(dir, "data.csv")
+	invalid := filepath.Join(dir, "invalid.csv")
+	if err := ioutil.WriteFile(invalid, []byte("1,true,1000,[]\n2,yes,1000,[]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{
+			name: "test1_fetch",
+			args: []string{"fetch", "-source", "http://ccli.test/users", "-snapshot=false", "-path", data},
+			want: exitOK,
+		},
+		{
+			name: "test2_search",
+			args: []string{"search", "-tag", "a", "-path", data},
+			want: exitOK,
+		},
+		{
+			name: "test3_search_no_results",
+			args: []string{"search", "-tag", "b", "-path", data},
+			want: exitNoResults,
+		},
+		{
+			name: "test4_missing_command",
+			args: nil,
+			want: exitUsage,
+		},
+		{
+			name: "test5_unknown_command",
+			args: []string{"nope"},
+			want: exitUsage,
+		},
+		{
+			name: "test6_bad_flag",
+			args: []string{"search", "-json-errors", "-nope"},
+			want: exitUsage,
+		},
+		{
+			name: "test7_bad_flag_value",
+			args: []string{"search", "-active", "maybe", "-path", data},
+			want: exitUsage,
+		},
+		{
+			name: "test8_fetch_source_down",
+			args: []string{"fetch", "-source", "http://ccli.test/missing", "-retries", "1", "-snapshot=false", "-path", data},
+			want: exitNetwork,
+		},
+		{
+			name: "test9_search_missing_file",
+			args: []string{"search", "-fetch-missing=false", "-path", filepath.Join(dir, "none.csv")},
+			want: exitStorage,
+		},
+		{
+			name: "test10_validate_invalid_rows",
+			args: []string{"validate", "-path", invalid},
+			want: exitStorage,
+		},
+		{
+			name: "test11_fetch_interrupted",
+			args: []string{"fetch", "-source", "http://ccli.test/hang", "-retries", "1", "-snapshot=false", "-path", data},
+			want: exitInterrupted,
+		},
+		{
+			name: "test12_help",
+			args: []string{"fetch", "-h"},
+			want: exitOK,
+		},
+		{
+			name: "test13_daemon_schedule_never_runs",
+			args: []string{"daemon", "-schedule", "0 0 30 2 *", "-path", data},
+			want: exitUsage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Run(tt.args); got != tt.want {
+				t.Errorf("Run(%q) = %v, want %v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
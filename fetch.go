@@ -0,0 +1,206 @@
+package ccli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rizaldihuzein/ccli/src"
+)
+
+// fetchFlags are shared by every command that may fetch from the sources.
+type fetchFlags struct {
+	fanOut      bool
+	merge       string
+	sourceSpecs stringList
+	configPath  string
+	retry       src.RetryPolicy
+	retryCodes  string
+	maxBody     int64
+	store       string
+	snapshot    bool
+	keep        int
+	compress    string
+}
+
+// fetchOptions selects how fetchAndStore gathers data from the sources.
+type fetchOptions struct {
+	fanOut bool
+	merge  src.MergeRule
+	merged bool
+	// client fetches from the sources given on the command line.
+	client *src.Client
+	// snapshot configures the snapshot taken after each fetch, when
+	// snapshotted is set.
+	snapshot    src.SnapshotOptions
+	snapshotted bool
+}
+
+func (f *fetchFlags) register(fs *flag.FlagSet) {
+	f.retry = src.DefaultRetryPolicy()
+	fs.BoolVar(&f.fanOut, "fanout", false, "request all sources in parallel and use the first valid response")
+	fs.StringVar(&f.merge, "merge", "", "fetch every source and merge their data, keeping the record per ID of the 'first' or 'last' source in the list")
+	fs.Var(&f.sourceSpecs, "source", "source spec URL[;method=GET][;timeout=5s][;priority=1][;header=Key: Value], can be repeated")
+	fs.StringVar(&f.configPath, "config", "", "JSON file listing the sources, used when -source is not set, takes precedence over "+src.SourcesEnv)
+	fs.IntVar(&f.retry.MaxAttempts, "retries", f.retry.MaxAttempts, "maximum attempts per source request, 1 disables retrying")
+	fs.DurationVar(&f.retry.BaseDelay, "retry-base", f.retry.BaseDelay, "wait before the first retry, doubled on each further retry")
+	fs.DurationVar(&f.retry.MaxDelay, "retry-max", f.retry.MaxDelay, "upper bound for a single wait between retries")
+	fs.Float64Var(&f.retry.Jitter, "retry-jitter", f.retry.Jitter, "fraction (0-1) of each wait to randomize")
+	fs.BoolVar(&f.retry.RespectRetryAfter, "retry-after", f.retry.RespectRetryAfter, "honor the Retry-After response header")
+	fs.StringVar(&f.retryCodes, "retry-codes", joinCodes(f.retry.RetryableCodes), "response codes to retry separated by comma")
+	fs.Int64Var(&f.maxBody, "max-body", src.DefaultMaxBodySize, "largest source response in bytes, -1 disables the limit")
+	fs.BoolVar(&f.snapshot, "snapshot", true, "copy the data file into <path>.snapshots after each fetch, see 'ccli diff'")
+	fs.IntVar(&f.keep, "keep", 10, "number of snapshots to keep, 0 keeps all of them")
+	fs.StringVar(&f.compress, "snapshot-compress", "", "compress the snapshots of a CSV or JSON data file: "+strings.Join(src.Compressions, ", "))
+	registerStore(fs, &f.store)
+}
+
+// build validates the parsed flags and returns a client with the requested
+// sources, retry policy and storage format.
+func (f *fetchFlags) build() (opt fetchOptions, err error) {
+	if f.fanOut && f.merge != "" {
+		return opt, errors.New("-fanout and -merge cannot be used together")
+	}
+
+	f.retry.RetryableCodes, err = parseCodes(f.retryCodes)
+	if err != nil {
+		return opt, err
+	}
+
+	if f.keep < 0 {
+		return opt, errors.New("-keep cannot be negative")
+	}
+	opt.snapshotted = f.snapshot
+	opt.snapshot.Keep = f.keep
+	opt.snapshot.Compression, err = src.ParseCompression(f.compress)
+	if err != nil {
+		return opt, err
+	}
+
+	opt.fanOut = f.fanOut
+	if f.merge != "" {
+		opt.merge, err = src.ParseMergeRule(f.merge)
+		if err != nil {
+			return opt, err
+		}
+		opt.merged = true
+	}
+
+	sources, err := src.LoadSources(f.configPath, f.sourceSpecs)
+	if err != nil {
+		return opt, err
+	}
+
+	opt.client, err = src.NewClient(src.ClientOptions{
+		Retry:       f.retry,
+		Store:       f.store,
+		MaxBodySize: f.maxBody,
+		Sources:     sources,
+	})
+	return opt, err
+}
+
+func fetch(ctx context.Context, opt fetchOptions) ([]src.UserData, error) {
+	switch {
+	case opt.merged:
+		return opt.client.FetchMerged(ctx, opt.merge)
+	case opt.fanOut:
+		return opt.client.FetchFanOut(ctx)
+	default:
+		return opt.client.Fetch(ctx)
+	}
+}
+
+// fetchAndStore replaces the data file at path with the fetched users. Users
+// from a single source are streamed into the file, fanned out and merged
+// fetches are collected first. Cancelling ctx stops the fetch and keeps the
+// previous file. Errors are classified for the exit code.
+func fetchAndStore(ctx context.Context, opt fetchOptions, path string) error {
+	if opt.merged || opt.fanOut {
+		data, err := fetch(ctx, opt)
+		if err != nil {
+			return networkError(err)
+		}
+		if err := opt.client.Store(ctx, data, path); err != nil {
+			return storageError(err)
+		}
+	} else if err := opt.client.FetchAndStore(ctx, path); err != nil {
+		return streamError(err)
+	}
+	return snapshot(ctx, opt, path)
+}
+
+// snapshot keeps a copy of the freshly written data file unless snapshots are
+// disabled.
+func snapshot(ctx context.Context, opt fetchOptions, path string) error {
+	if !opt.snapshotted {
+		return nil
+	}
+	_, err := opt.client.Snapshot(ctx, path, opt.snapshot)
+	if err != nil {
+		return storageError(fmt.Errorf("data stored but snapshot failed: %w", err))
+	}
+	return nil
+}
+
+func runFetch(args []string) error {
+	var (
+		fs        = newFlagSet("fetch", "Fetch users from the sources and replace the data file with them.")
+		path      = fs.String("path", defaultPath, "data file to write")
+		upsert    = fs.Bool("upsert", false, "merge the fetched users into the data file by ID instead of replacing it")
+		tombstone = fs.String("tombstone", "", "with -upsert, what to do with stored users missing from the fetch: keep, delete or deactivate (default keep)")
+		timeout   time.Duration
+		flags     fetchFlags
+	)
+	flags.register(fs)
+	registerTimeout(fs, &timeout)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext(timeout)
+	defer cancel()
+
+	var policy src.TombstonePolicy
+	if *tombstone != "" {
+		if !*upsert {
+			return usageError(errors.New("-tombstone requires -upsert"))
+		}
+		var err error
+		policy, err = src.ParseTombstonePolicy(*tombstone)
+		if err != nil {
+			return usageError(err)
+		}
+	}
+
+	opt, err := flags.build()
+	if err != nil {
+		return usageError(err)
+	}
+
+	if *upsert {
+		data, err := fetch(ctx, opt)
+		if err != nil {
+			return networkError(err)
+		}
+		result, err := opt.client.Upsert(ctx, data, *path, policy)
+		if err != nil {
+			return storageError(err)
+		}
+		if err := snapshot(ctx, opt, *path); err != nil {
+			return err
+		}
+		fmt.Printf("Upserted fetched users in %s: %d added, %d updated, %d unchanged, %d deleted, %d deactivated\n",
+			*path, result.Added, result.Updated, result.Unchanged, result.Deleted, result.Deactivated)
+		return nil
+	}
+
+	if err := fetchAndStore(ctx, opt, *path); err != nil {
+		return err
+	}
+	fmt.Printf("Stored fetched users in %s\n", *path)
+	return nil
+}
@@ -0,0 +1,41 @@
+package ccli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rizaldihuzein/ccli/src"
+)
+
+func runMigrate(args []string) error {
+	var (
+		fs      = newFlagSet("migrate", "Rewrite a CSV file written by an older ccli in the current schema version.")
+		path    = fs.String("path", defaultPath, "data file to migrate")
+		timeout time.Duration
+		store   string
+	)
+	registerStore(fs, &store)
+	registerTimeout(fs, &timeout)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext(timeout)
+	defer cancel()
+
+	client, err := build(store)
+	if err != nil {
+		return usageError(err)
+	}
+	migrated, err := client.Migrate(ctx, *path)
+	if err != nil {
+		return storageError(err)
+	}
+
+	if !migrated {
+		fmt.Printf("%s: already at schema version %d\n", *path, src.SchemaVersion)
+		return nil
+	}
+	fmt.Printf("%s: migrated to schema version %d\n", *path, src.SchemaVersion)
+	return nil
+}
@@ -0,0 +1,149 @@
+package ccli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rizaldihuzein/ccli/src"
+)
+
+// filterFlags are the search conditions accepted on the command line.
+type filterFlags struct {
+	tags       string
+	query      string
+	active     string
+	minBalance string
+	maxBalance string
+}
+
+func (f *filterFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.tags, "tag", "", "tags to search separated by comma, e.g. -tag=sed,quis")
+	fs.StringVar(&f.query, "query", "", "boolean tag expression, e.g. -query='sed AND (quis OR NOT dolor)'")
+	fs.StringVar(&f.active, "active", "", "keep only active (true) or inactive (false) users")
+	fs.StringVar(&f.minBalance, "min-balance", "", "keep users with at least this balance, e.g. -min-balance='$1,000'")
+	fs.StringVar(&f.maxBalance, "max-balance", "", "keep users with at most this balance, users whose balance cannot be parsed are left out of either bound")
+}
+
+func (f *filterFlags) build() (filter src.Filter, err error) {
+	if f.tags != "" && f.query != "" {
+		return filter, errors.New("-tag and -query cannot be used together")
+	}
+
+	filter.Query = src.AllTagsQuery(splitList(f.tags))
+	if f.query != "" {
+		filter.Query, err = src.ParseQuery(f.query)
+		if err != nil {
+			return filter, err
+		}
+	}
+
+	if f.active != "" {
+		active, err := strconv.ParseBool(f.active)
+		if err != nil {
+			return filter, fmt.Errorf("invalid -active value %q", f.active)
+		}
+		filter.Active = &active
+	}
+
+	if f.minBalance != "" {
+		low, err := src.ParseMoney(f.minBalance)
+		if err != nil {
+			return filter, err
+		}
+		filter.MinBalance = &low
+	}
+
+	if f.maxBalance != "" {
+		high, err := src.ParseMoney(f.maxBalance)
+		if err != nil {
+			return filter, err
+		}
+		filter.MaxBalance = &high
+	}
+
+	return filter, nil
+}
+
+func runSearch(args []string) error {
+	var (
+		fs           = newFlagSet("search", "Search the data file for users having every given tag, or matching a -query,\noptionally narrowed by active status and balance.\nWithout any condition every user is listed.")
+		path         = fs.String("path", defaultPath, "data file to search")
+		fetchMissing = fs.Bool("fetch-missing", true, "fetch from the sources first when the data file does not exist")
+		all          = fs.Bool("all", false, "print every stored field instead of only ID and balance in text output")
+		output       = fs.String("output", src.FormatText, "output format: "+strings.Join(src.Formats, ", "))
+		fields       = fs.String("fields", "", "fields to print separated by comma, any of "+strings.Join(src.FieldNames(), ","))
+		limit        = fs.Int("limit", 0, "stop after printing this many users, 0 prints all of them")
+		filterOpt    filterFlags
+		timeout      time.Duration
+		flags        fetchFlags
+	)
+	filterOpt.register(fs)
+	flags.register(fs)
+	registerTimeout(fs, &timeout)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext(timeout)
+	defer cancel()
+
+	filter, err := filterOpt.build()
+	if err != nil {
+		return usageError(err)
+	}
+	if *limit < 0 {
+		return usageError(errors.New("-limit cannot be negative"))
+	}
+
+	fieldList := splitList(*fields)
+	if len(fieldList) == 0 && *output == src.FormatText && !*all {
+		fieldList = []string{"id", "balance"}
+	}
+	writer, err := src.NewUserWriter(os.Stdout, *output, fieldList)
+	if err != nil {
+		return usageError(err)
+	}
+
+	opt, err := flags.build()
+	if err != nil {
+		return usageError(err)
+	}
+
+	// Users are written as soon as they are read, the search stops once
+	// limit users are written.
+	count := 0
+	write := func(user src.UserData) error {
+		if err := writer.Write(user); err != nil {
+			return err
+		}
+		count++
+		if *limit > 0 && count >= *limit {
+			return src.ErrStopSearch
+		}
+		return nil
+	}
+
+	err = opt.client.SearchEach(ctx, filter, *path, write)
+	if errors.Is(err, src.ErrMissingFile) && *fetchMissing {
+		fmt.Fprintln(os.Stderr, "Data file not found, generating new one...")
+		if err := fetchAndStore(ctx, opt, *path); err != nil {
+			return err
+		}
+		err = opt.client.SearchEach(ctx, filter, *path, write)
+	}
+	if err != nil {
+		return storageError(err)
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	if count == 0 {
+		return errNoResults
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package ccli
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/rizaldihuzein/ccli/src"
+)
+
+func runServe(args []string) error {
+	var (
+		fs    = newFlagSet("serve", "Serve the data file as a JSON API:\n  GET  /users?tags=a,b&active=true  search, accepting tags, query, active,\n                                   min-balance, max-balance and limit\n  GET  /users/{id}                  a single user\n  POST /refresh                     fetch from the sources again")
+		addr  = fs.String("addr", ":8080", "address to listen on")
+		path  = fs.String("path", defaultPath, "data file to serve")
+		flags fetchFlags
+	)
+	flags.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	opt, err := flags.build()
+	if err != nil {
+		return usageError(err)
+	}
+
+	handler := opt.client.Handler(src.ServerOptions{
+		Path: *path,
+		Refresh: func(ctx context.Context) error {
+			return fetchAndStore(ctx, opt, *path)
+		},
+	})
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := commandContext(0)
+	defer stop()
+	// On SIGINT or SIGTERM the server stops accepting connections and waits
+	// for the running requests.
+	idle := make(chan struct{})
+	go func() {
+		defer close(idle)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Shutdown: %v", err)
+		}
+	}()
+
+	log.Printf("Serving %s on %s", *path, *addr)
+	err = server.ListenAndServe()
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	<-idle
+	return nil
+}
@@ -0,0 +1,124 @@
+package src
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Storage formats accepted by ClientOptions.Store and NewStorage.
+const (
+	StoreCSV    = "csv"
+	StoreJSON   = "json"
+	StoreNDJSON = "ndjson"
+	StoreKV     = "kv"
+	StoreSQLite = "sqlite"
+)
+
+// StoreFormats lists every storage format in the order shown to users.
+var StoreFormats = []string{StoreCSV, StoreJSON, StoreNDJSON, StoreKV, StoreSQLite}
+
+type (
+	// backendIface encodes users in one storage format. storage takes care of
+	// opening paths and replacing files atomically.
+	backendIface interface {
+		// write encodes data into file, a new and empty file that replaces
+		// path once write succeeds.
+		write(ctx context.Context, file *os.File, path string, users userSeq) error
+		// scan calls fn with every user stored at path, in order, and returns
+		// the schema version of the file. A user that cannot be decoded is
+		// passed with rowErr set to a *ParseError. ErrMissingFile is wrapped
+		// when path does not exist, and scan stops at the first error
+		// returned by fn.
+		scan(ctx context.Context, path string, fn scanFunc) (version int, err error)
+	}
+
+	// tagIndexIface is implemented by backends able to look rows up by tag.
+	tagIndexIface interface {
+		// scanTags is like scan but may skip every user missing one of tags.
+		scanTags(ctx context.Context, path string, tags []string, fn scanFunc) (version int, err error)
+	}
+
+	// idLookupIface is implemented by backends keyed by user ID.
+	idLookupIface interface {
+		// lookupID reads the user stored under id, found is false when
+		// there is none.
+		lookupID(ctx context.Context, path, id string) (user UserData, found bool, err error)
+	}
+
+	// scanFunc receives one stored user along with its row, counted from 1
+	// in the unit of the format: a record, an array item or a line.
+	scanFunc func(row int, user UserData, rowErr error) error
+)
+
+// ParseStoreFormat validates a storage format name. An empty name is
+// accepted, it picks the format from the extension of each path.
+func ParseStoreFormat(s string) (string, error) {
+	format := strings.ToLower(strings.TrimSpace(s))
+	if format == "" {
+		return "", nil
+	}
+	for _, v := range StoreFormats {
+		if v == format {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown storage format %q, expected one of %s", s, strings.Join(StoreFormats, ", "))
+}
+
+// storeFormatFromPath picks the storage format from the file extension,
+// ignoring a compression extension, falling back to CSV.
+func storeFormatFromPath(path string) string {
+	_, path = compressionFromPath(path)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return StoreJSON
+	case ".ndjson", ".jsonl":
+		return StoreNDJSON
+	case ".db", ".kv", ".bolt":
+		return StoreKV
+	case ".sqlite", ".sqlite3":
+		return StoreSQLite
+	}
+	return StoreCSV
+}
+
+// backend returns the backend of the configured format, or of the path
+// extension when none is configured.
+func (s *storage) backend(path string) backendIface {
+	format := s.format
+	if format == "" {
+		format = storeFormatFromPath(path)
+	}
+
+	if compression, _ := compressionFromPath(path); compression != "" && (format == StoreKV || format == StoreSQLite) {
+		return errBackend{fmt.Errorf("%s storage cannot be %s compressed", format, compression)}
+	}
+
+	switch format {
+	case StoreJSON:
+		return &jsonBackend{fileReader: s.fileReader}
+	case StoreNDJSON:
+		return &jsonBackend{fileReader: s.fileReader, lines: true}
+	case StoreKV:
+		return &kvBackend{}
+	case StoreSQLite:
+		return &sqliteBackend{}
+	}
+	return &csvBackend{fileReader: s.fileReader, csvHandler: s.csvHandler}
+}
+
+// errBackend fails every operation with err, for paths no backend can handle.
+type errBackend struct {
+	err error
+}
+
+func (b errBackend) write(ctx context.Context, file *os.File, path string, users userSeq) error {
+	return b.err
+}
+
+func (b errBackend) scan(ctx context.Context, path string, fn scanFunc) (version int, err error) {
+	return 0, b.err
+}
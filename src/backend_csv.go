@@ -0,0 +1,162 @@
+package src
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+)
+
+// csvBackend stores users as CSV records under a schema marker and header,
+// see csvHeader, along with an inverted tag index, see csvIndex. Compressed
+// files have no index, offsets into them cannot be seeked to.
+type csvBackend struct {
+	fileReader fReaderIface
+	csvHandler csvHandlerIface
+}
+
+func (b *csvBackend) write(ctx context.Context, file *os.File, path string, users userSeq) error {
+	cw, err := compress(file, path)
+	if err != nil {
+		return err
+	}
+	compression, _ := compressionFromPath(path)
+
+	// The writer is flushed after every record so sum knows where the next
+	// one starts, buf keeps that from turning into a write per record.
+	buf := bufio.NewWriter(cw)
+	sum := newChecksumWriter(buf)
+	writer := b.csvHandler.NewWriter(sum)
+	header := csvHeader()
+	for _, v := range header {
+		if err := writer.Write(v); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+
+	index := newCSVIndex(len(header) + 1)
+	err = users(func(v UserData) error {
+		record, err := csvRecord(v)
+		if err != nil {
+			return err
+		}
+		index.add(sum.n, v.Tags)
+		err = writer.Write(record)
+		if err != nil {
+			return err
+		}
+		writer.Flush()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+	if err := cw.Close(); err != nil {
+		return err
+	}
+	if compression != "" {
+		return nil
+	}
+
+	index.Size, index.CRC32C = sum.n, sum.crc.Sum32()
+	return b.writeIndex(path, index)
+}
+
+// scanTags seeks to the records listed in the tag index for every tag. It
+// falls back to scan when the index is missing or stale.
+func (b *csvBackend) scanTags(ctx context.Context, path string, tags []string, fn scanFunc) (version int, err error) {
+	var (
+		index   *csvIndex
+		entries []indexEntry
+		ok      bool
+	)
+	if compression, _ := compressionFromPath(path); compression == "" {
+		index = b.loadIndex(path)
+	}
+	if index != nil {
+		entries, ok = index.lookup(tags)
+	}
+	if !ok {
+		return b.scan(ctx, path, fn)
+	}
+
+	file, err := b.fileReader.Open(path)
+	if err != nil {
+		return 0, openError(err)
+	}
+	defer file.Close()
+
+	csvReader, err := newCSVUserReader(b.csvHandler.NewReader(bufio.NewReader(file)))
+	if err != nil {
+		return 0, err
+	}
+	for _, e := range entries {
+		if _, err := file.Seek(e.offset, io.SeekStart); err != nil {
+			return csvReader.version, err
+		}
+		res, err := b.csvHandler.NewReader(bufio.NewReader(file)).Read()
+		if err != nil {
+			return csvReader.version, err
+		}
+
+		row := index.FirstRow + e.pos
+		user, err := csvReader.parse(res)
+		if err := fn(row, user, rowError(row, err)); err != nil {
+			return csvReader.version, err
+		}
+	}
+
+	return csvReader.version, nil
+}
+
+func (b *csvBackend) scan(ctx context.Context, path string, fn scanFunc) (version int, err error) {
+	file, err := b.fileReader.Open(path)
+	if err != nil {
+		return 0, openError(err)
+	}
+	defer file.Close()
+
+	r, err := decompress(file, path)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	csvReader, err := newCSVUserReader(b.csvHandler.NewReader(bufio.NewReader(r)))
+	if err != nil {
+		return 0, err
+	}
+	for {
+		res, row, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return csvReader.version, err
+			}
+			if err := fn(row, UserData{}, rowError(row, err)); err != nil {
+				return csvReader.version, err
+			}
+			continue
+		}
+
+		user, err := csvReader.parse(res)
+		if err := fn(row, user, rowError(row, err)); err != nil {
+			return csvReader.version, err
+		}
+	}
+
+	return csvReader.version, nil
+}
@@ -0,0 +1,135 @@
+package src
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// jsonBackend stores users as a JSON array of the objects served by the
+// sources, or with lines set as one such object per line (NDJSON).
+type jsonBackend struct {
+	fileReader fReaderIface
+	lines      bool
+}
+
+func (b *jsonBackend) write(ctx context.Context, file *os.File, path string, users userSeq) error {
+	cw, err := compress(file, path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(cw)
+	if !b.lines {
+		w.WriteString("[")
+	}
+	count := 0
+	err = users(func(v UserData) error {
+		obj, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		switch {
+		case b.lines:
+		case count == 0:
+			w.WriteString("\n  ")
+		default:
+			w.WriteString(",\n  ")
+		}
+		count++
+		w.Write(obj)
+		if b.lines {
+			w.WriteByte('\n')
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !b.lines {
+		if count > 0 {
+			w.WriteString("\n")
+		}
+		w.WriteString("]\n")
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return cw.Close()
+}
+
+func (b *jsonBackend) scan(ctx context.Context, path string, fn scanFunc) (version int, err error) {
+	file, err := b.fileReader.Open(path)
+	if err != nil {
+		return 0, openError(err)
+	}
+	defer file.Close()
+
+	r, err := decompress(file, path)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	if b.lines {
+		return SchemaVersion, scanJSONLines(bufio.NewReader(r), fn)
+	}
+	return SchemaVersion, scanJSONArray(bufio.NewReader(r), fn)
+}
+
+// scanJSONArray decodes the items of a JSON array one at a time. An item of
+// the wrong type is reported to fn, malformed JSON stops the scan.
+func scanJSONArray(r io.Reader, fn scanFunc) error {
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("json: %w: expected an array of users", ErrMalformedFile)
+	}
+
+	for row := 1; dec.More(); row++ {
+		var user UserData
+		err := dec.Decode(&user)
+		var typeErr *json.UnmarshalTypeError
+		if err != nil && !errors.As(err, &typeErr) {
+			return fmt.Errorf("json: %w: item %d: %v", ErrMalformedFile, row, err)
+		}
+		if err := fn(row, user, rowError(row, err)); err != nil {
+			return err
+		}
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("json: %w: %v", ErrMalformedFile, err)
+	}
+	return nil
+}
+
+// scanJSONLines decodes one user per line, skipping blank lines.
+func scanJSONLines(r *bufio.Reader, fn scanFunc) error {
+	for row := 1; ; row++ {
+		line, err := r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if len(bytes.TrimSpace(line)) > 0 {
+			var user UserData
+			rowErr := json.Unmarshal(line, &user)
+			if err := fn(row, user, rowError(row, rowErr)); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
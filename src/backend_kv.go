@@ -0,0 +1,138 @@
+package src
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	kvUsersBucket = []byte("users")
+	kvOrderBucket = []byte("order")
+	kvMetaBucket  = []byte("meta")
+	kvVersionKey  = []byte("version")
+)
+
+// kvBackend stores users in an embedded bbolt database. The users bucket
+// holds each user as a JSON object under its ID, so a user is looked up
+// without a scan, and the order bucket lists the IDs under sequence keys in
+// the order they were written. IDs are unique keys: an empty or repeated ID
+// fails the write.
+type kvBackend struct{}
+
+func (b *kvBackend) write(ctx context.Context, file *os.File, path string, users userSeq) error {
+	db, err := bolt.Open(file.Name(), 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucket(kvMetaBucket)
+		if err != nil {
+			return err
+		}
+		err = meta.Put(kvVersionKey, []byte(strconv.Itoa(SchemaVersion)))
+		if err != nil {
+			return err
+		}
+
+		bucket, err := tx.CreateBucket(kvUsersBucket)
+		if err != nil {
+			return err
+		}
+		order, err := tx.CreateBucket(kvOrderBucket)
+		if err != nil {
+			return err
+		}
+		var seq uint64
+		return users(func(v UserData) error {
+			seq++
+			id := []byte(v.ID)
+			switch {
+			case len(id) == 0:
+				return fmt.Errorf("kv: user %d: %w", seq, ErrEmptyID)
+			case bucket.Get(id) != nil:
+				return fmt.Errorf("kv: user %d: %w %q", seq, ErrDuplicateID, v.ID)
+			}
+			obj, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put(id, obj); err != nil {
+				return err
+			}
+			key := make([]byte, 8)
+			binary.BigEndian.PutUint64(key, seq)
+			return order.Put(key, id)
+		})
+	})
+	if err != nil {
+		db.Close()
+		return err
+	}
+	return db.Close()
+}
+
+func (b *kvBackend) scan(ctx context.Context, path string, fn scanFunc) (version int, err error) {
+	return b.view(path, func(users, order *bolt.Bucket) error {
+		row := 0
+		return order.ForEach(func(k, id []byte) error {
+			row++
+			var user UserData
+			rowErr := json.Unmarshal(users.Get(id), &user)
+			return fn(row, user, rowError(row, rowErr))
+		})
+	})
+}
+
+// lookupID reads the user stored under id, found is false when there is
+// none.
+func (b *kvBackend) lookupID(ctx context.Context, path, id string) (user UserData, found bool, err error) {
+	_, err = b.view(path, func(users, order *bolt.Bucket) error {
+		obj := users.Get([]byte(id))
+		if obj == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(obj, &user)
+	})
+	return user, found, err
+}
+
+// view opens the database at path read only, checks its schema version and
+// calls fn with its users and order buckets.
+func (b *kvBackend) view(path string, fn func(users, order *bolt.Bucket) error) (version int, err error) {
+	// bolt creates missing files, even when opened read only.
+	if _, err := os.Stat(path); err != nil {
+		return 0, openError(err)
+	}
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: time.Second, ReadOnly: true})
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	err = db.View(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(kvMetaBucket)
+		users := tx.Bucket(kvUsersBucket)
+		order := tx.Bucket(kvOrderBucket)
+		if meta == nil || users == nil || order == nil {
+			return fmt.Errorf("kv: %w: missing users bucket", ErrBadSchema)
+		}
+		version, err = strconv.Atoi(string(meta.Get(kvVersionKey)))
+		if err != nil {
+			return fmt.Errorf("kv: %w: bad version %q", ErrBadSchema, meta.Get(kvVersionKey))
+		}
+		if version > SchemaVersion {
+			return fmt.Errorf("kv: %w %d, the newest supported is %d", ErrUnsupportedSchema, version, SchemaVersion)
+		}
+		return fn(users, order)
+	})
+	return version, err
+}
@@ -0,0 +1,201 @@
+package src
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema creates the tables of a new database. tags keeps the encoded
+// tag list so users read back exactly as written, user_tags holds one row per
+// tag for indexed lookups.
+const sqliteSchema = `
+CREATE TABLE meta (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+CREATE TABLE users (
+	row     INTEGER PRIMARY KEY,
+	id      TEXT NOT NULL,
+	active  INTEGER NOT NULL,
+	balance TEXT NOT NULL,
+	tags    TEXT NOT NULL
+);
+CREATE TABLE user_tags (
+	user_row INTEGER NOT NULL REFERENCES users(row),
+	tag      TEXT NOT NULL
+);
+CREATE INDEX user_tags_tag ON user_tags(tag, user_row);
+`
+
+// sqliteBackend stores users in an embedded SQLite database through the pure
+// Go driver modernc.org/sqlite, no cgo is needed.
+type sqliteBackend struct{}
+
+// sqliteDSN returns the URI opening the database at path with the given URI
+// query. The path is made absolute, a relative one would be read as the URI
+// authority, and escaped so that "?", "#" and "%" are taken literally.
+func sqliteDSN(path, query string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	abs = filepath.ToSlash(abs)
+	if !strings.HasPrefix(abs, "/") {
+		// a Windows drive letter
+		abs = "/" + abs
+	}
+	u := url.URL{Scheme: "file", Path: abs, RawQuery: query}
+	return u.String(), nil
+}
+
+func (b *sqliteBackend) write(ctx context.Context, file *os.File, path string, users userSeq) error {
+	dsn, err := sqliteDSN(file.Name(), "")
+	if err != nil {
+		return err
+	}
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, sqliteSchema); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO meta (key, value) VALUES ('version', ?)`, strconv.Itoa(SchemaVersion))
+	if err != nil {
+		return err
+	}
+
+	userStmt, err := tx.PrepareContext(ctx, `INSERT INTO users (row, id, active, balance, tags) VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer userStmt.Close()
+	tagStmt, err := tx.PrepareContext(ctx, `INSERT INTO user_tags (user_row, tag) VALUES (?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer tagStmt.Close()
+
+	row := 0
+	err = users(func(v UserData) error {
+		tagBytes, err := json.Marshal(&v.Tags)
+		if err != nil {
+			return err
+		}
+		row++
+		_, err = userStmt.ExecContext(ctx, row, v.ID, v.ActiveStatus, v.Balance, string(tagBytes))
+		if err != nil {
+			return err
+		}
+		for _, tag := range v.Tags {
+			if _, err := tagStmt.ExecContext(ctx, row, tag); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return db.Close()
+}
+
+func (b *sqliteBackend) scan(ctx context.Context, path string, fn scanFunc) (version int, err error) {
+	return b.query(ctx, path, `SELECT row, id, active, balance, tags FROM users ORDER BY row`, nil, fn)
+}
+
+// scanTags only reads the users having every tag, found through the
+// user_tags index.
+func (b *sqliteBackend) scanTags(ctx context.Context, path string, tags []string, fn scanFunc) (version int, err error) {
+	unique := make(map[string]struct{}, len(tags))
+	args := make([]interface{}, 0, len(tags)+1)
+	for _, v := range tags {
+		if _, ok := unique[v]; ok {
+			continue
+		}
+		unique[v] = struct{}{}
+		args = append(args, v)
+	}
+	args = append(args, len(unique))
+
+	query := `SELECT row, id, active, balance, tags FROM users WHERE row IN (
+		SELECT user_row FROM user_tags WHERE tag IN (?` + strings.Repeat(", ?", len(unique)-1) + `)
+		GROUP BY user_row HAVING COUNT(DISTINCT tag) = ?
+	) ORDER BY row`
+	return b.query(ctx, path, query, args, fn)
+}
+
+func (b *sqliteBackend) query(ctx context.Context, path, query string, args []interface{}, fn scanFunc) (version int, err error) {
+	// Opening a missing file would create an empty database.
+	if _, err := os.Stat(path); err != nil {
+		return 0, openError(err)
+	}
+	dsn, err := sqliteDSN(path, "mode=ro")
+	if err != nil {
+		return 0, err
+	}
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	var value string
+	err = db.QueryRowContext(ctx, `SELECT value FROM meta WHERE key = 'version'`).Scan(&value)
+	if err != nil {
+		return 0, err
+	}
+	version, err = strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("sqlite: %w: bad version %q", ErrBadSchema, value)
+	}
+	if version > SchemaVersion {
+		return version, fmt.Errorf("sqlite: %w %d, the newest supported is %d", ErrUnsupportedSchema, version, SchemaVersion)
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return version, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			row  int
+			user UserData
+			tags string
+		)
+		if err := rows.Scan(&row, &user.ID, &user.ActiveStatus, &user.Balance, &tags); err != nil {
+			return version, err
+		}
+		var rowErr error
+		if err := json.Unmarshal([]byte(tags), &user.Tags); err != nil {
+			rowErr = &ParseError{Row: row, Column: "tags", Err: err}
+		}
+		if err := fn(row, user, rowErr); err != nil {
+			return version, err
+		}
+	}
+	return version, rows.Err()
+}
@@ -0,0 +1,101 @@
+package src
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_sqliteBackend_scanTags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.sqlite")
+	s := newStorage()
+	data := []UserData{
+		{ID: "1", Tags: []string{"a", "b"}},
+		{ID: "2", Tags: []string{"b", "b"}},
+		{ID: "3", Tags: []string{"a", "c"}},
+		{ID: "4"},
+	}
+	if err := s.storeAndReplace(context.Background(), data, path); err != nil {
+		t.Fatalf("storage.storeAndReplace() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		tags    []string
+		wantIDs []string
+	}{
+		{
+			name:    "test1_single",
+			tags:    []string{"b"},
+			wantIDs: []string{"1", "2"},
+		},
+		{
+			name:    "test2_every_tag",
+			tags:    []string{"a", "b", "a"},
+			wantIDs: []string{"1"},
+		},
+		{
+			name: "test3_unknown",
+			tags: []string{"z"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []string
+			_, err := (&sqliteBackend{}).scanTags(context.Background(), path, tt.tags, func(row int, user UserData, rowErr error) error {
+				ids = append(ids, user.ID)
+				return rowErr
+			})
+			if err != nil {
+				t.Fatalf("sqliteBackend.scanTags() error = %v", err)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("sqliteBackend.scanTags() = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func Test_sqliteBackend_specialPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a?b#c%41", "data.sqlite")
+	if err := (&fileHandler{}).MkdirAll(filepath.Dir(path)); err != nil {
+		t.Fatal(err)
+	}
+	s := newStorage()
+	data := []UserData{{ID: "1", Tags: []string{"a"}}}
+	if err := s.storeAndReplace(context.Background(), data, path); err != nil {
+		t.Fatalf("storage.storeAndReplace() error = %v", err)
+	}
+	got, err := s.search(context.Background(), []string{"a"}, path)
+	if err != nil || !reflect.DeepEqual(got, data) {
+		t.Errorf("storage.search() = %v, %v, want %v", got, err, data)
+	}
+	// Nothing was created at the unescaped paths.
+	matches, _ := filepath.Glob(filepath.Join(filepath.Dir(filepath.Dir(path)), "*"))
+	if len(matches) != 1 {
+		t.Errorf("files = %v, want only %s", matches, filepath.Dir(path))
+	}
+}
+
+func Test_sqliteBackend_relativePath(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	s := newStorage()
+	data := []UserData{{ID: "1", Tags: []string{"a"}}}
+	if err := s.storeAndReplace(context.Background(), data, "data.sqlite"); err != nil {
+		t.Fatalf("storage.storeAndReplace() error = %v", err)
+	}
+	got, err := s.search(context.Background(), []string{"a"}, "data.sqlite")
+	if err != nil || !reflect.DeepEqual(got, data) {
+		t.Errorf("storage.search() = %v, %v, want %v", got, err, data)
+	}
+}
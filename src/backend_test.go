@@ -0,0 +1,324 @@
+package src
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseStoreFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "test1_empty",
+			s:    "",
+			want: "",
+		},
+		{
+			name: "test2_case_insensitive",
+			s:    " NDJSON ",
+			want: StoreNDJSON,
+		},
+		{
+			name:    "test3_unknown",
+			s:       "xml",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseStoreFormat(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseStoreFormat() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseStoreFormat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_storage_backend(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		path   string
+		want   backendIface
+	}{
+		{
+			name: "test1_default_csv",
+			path: "data.txt",
+			want: &csvBackend{fileReader: &fileHandler{}, csvHandler: &csvHandler{}},
+		},
+		{
+			name: "test2_json_extension",
+			path: "dir/data.JSON",
+			want: &jsonBackend{fileReader: &fileHandler{}},
+		},
+		{
+			name: "test3_ndjson_extension",
+			path: "data.jsonl",
+			want: &jsonBackend{fileReader: &fileHandler{}, lines: true},
+		},
+		{
+			name: "test4_kv_extension",
+			path: "data.db",
+			want: &kvBackend{},
+		},
+		{
+			name:   "test5_format_over_extension",
+			format: StoreNDJSON,
+			path:   "data.csv",
+			want:   &jsonBackend{fileReader: &fileHandler{}, lines: true},
+		},
+		{
+			name: "test6_sqlite_extension",
+			path: "data.sqlite3",
+			want: &sqliteBackend{},
+		},
+		{
+			name: "test7_compressed_extension",
+			path: "data.ndjson.zst",
+			want: &jsonBackend{fileReader: &fileHandler{}, lines: true},
+		},
+		{
+			name: "test8_compressed_database",
+			path: "data.sqlite.gz",
+			want: errBackend{errors.New("sqlite storage cannot be gzip compressed")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStorageWithFormat(tt.format).(*storage)
+			if got := s.backend(tt.path); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("storage.backend() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_storage_backends(t *testing.T) {
+	data := []UserData{
+		{ID: "1", ActiveStatus: true, Balance: "$1,000.00", Tags: []string{"a", "b"}},
+		{ID: "2", Balance: "$2.50", Tags: []string{"b"}},
+		{ID: "3", ActiveStatus: true, Balance: "$0.00"},
+	}
+
+	for _, format := range StoreFormats {
+		t.Run(format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "data.out")
+			s := newStorageWithFormat(format)
+
+			if _, err := s.search(context.Background(), nil, path); !errors.Is(err, ErrMissingFile) {
+				t.Fatalf("storage.search() error = %v, want %v", err, ErrMissingFile)
+			}
+
+			if err := s.storeAndReplace(context.Background(), data, path); err != nil {
+				t.Fatalf("storage.storeAndReplace() error = %v", err)
+			}
+
+			got, err := s.search(context.Background(), nil, path)
+			if err != nil {
+				t.Fatalf("storage.search() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, data) {
+				t.Errorf("storage.search() = %v, want %v", got, data)
+			}
+
+			got, err = s.search(context.Background(), []string{"b"}, path)
+			if err != nil {
+				t.Fatalf("storage.search() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, data[:2]) {
+				t.Errorf("storage.search() = %v, want %v", got, data[:2])
+			}
+
+			user, found, err := s.lookup(context.Background(), "2", path)
+			if err != nil || !found || !reflect.DeepEqual(user, data[1]) {
+				t.Errorf("storage.lookup() = %v, %v, %v, want %v", user, found, err, data[1])
+			}
+			if _, found, err := s.lookup(context.Background(), "9", path); err != nil || found {
+				t.Errorf("storage.lookup() = %v, %v, want not found", found, err)
+			}
+
+			report, err := s.inspect(context.Background(), path)
+			if err != nil {
+				t.Fatalf("storage.inspect() error = %v", err)
+			}
+			if report.Version != SchemaVersion || report.Rows != 3 || report.Active != 2 || len(report.Problems) != 0 {
+				t.Errorf("storage.inspect() = %+v", report)
+			}
+
+			if err := s.storeAndReplace(context.Background(), nil, path); err != nil {
+				t.Fatalf("storage.storeAndReplace() error = %v", err)
+			}
+			got, err = s.search(context.Background(), nil, path)
+			if err != nil || len(got) != 0 {
+				t.Errorf("storage.search() = %v, %v, want no users", got, err)
+			}
+		})
+	}
+}
+
+func Test_kvBackend_write_ids(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []UserData
+		wantIs error
+	}{
+		{
+			name:   "test1_empty_id",
+			data:   []UserData{{ID: "1"}, {ID: ""}},
+			wantIs: ErrEmptyID,
+		},
+		{
+			name:   "test2_duplicated_id",
+			data:   []UserData{{ID: "1"}, {ID: "2"}, {ID: "1"}},
+			wantIs: ErrDuplicateID,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "data.db")
+			s := newStorage()
+			data := []UserData{{ID: "0"}}
+			if err := s.storeAndReplace(context.Background(), data, path); err != nil {
+				t.Fatalf("storage.storeAndReplace() error = %v", err)
+			}
+
+			if err := s.storeAndReplace(context.Background(), tt.data, path); !errors.Is(err, tt.wantIs) {
+				t.Errorf("storage.storeAndReplace() error = %v, want %v", err, tt.wantIs)
+			}
+			// The previous file is kept.
+			if got, err := s.search(context.Background(), nil, path); err != nil || !reflect.DeepEqual(got, data) {
+				t.Errorf("storage.search() = %v, %v, want %v", got, err, data)
+			}
+		})
+	}
+}
+
+func Test_storage_inspect_json(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	s := newStorage()
+
+	tests := []struct {
+		name         string
+		path         string
+		wantRows     int
+		wantProblems []string
+		wantErr      bool
+	}{
+		{
+			name: "test1_json",
+			path: write("a.json", `[
+				{"_id": "1", "isActive": true, "balance": "$1.00", "tags": ["a"]},
+				{"_id": "2", "isActive": "yes", "balance": "$1.00", "tags": []},
+				{"_id": "1", "isActive": true, "balance": "$1.00", "tags": []}
+			]`),
+			wantRows: 1,
+			wantProblems: []string{
+				"row 2: json: cannot unmarshal string into Go struct field UserData.isActive of type bool",
+				"row 3: duplicated id \"1\", first seen on row 1",
+			},
+		},
+		{
+			name:    "test2_json_not_an_array",
+			path:    write("b.json", `{"_id": "1"}`),
+			wantErr: true,
+		},
+		{
+			name:    "test3_json_malformed",
+			path:    write("c.json", `[{"_id": "1"}, {`),
+			wantErr: true,
+		},
+		{
+			name:     "test4_ndjson",
+			path:     write("d.ndjson", "{\"_id\": \"1\", \"tags\": []}\n\n{\"_id\": \n{\"_id\": \"\"}\n"),
+			wantRows: 1,
+			wantProblems: []string{
+				"row 3: unexpected end of JSON input",
+				"row 4: empty id",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.inspect(context.Background(), tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("storage.inspect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Rows != tt.wantRows {
+				t.Errorf("storage.inspect() rows = %d, want %d", got.Rows, tt.wantRows)
+			}
+			problems := []string{}
+			for _, v := range got.Problems {
+				problems = append(problems, v.Error())
+			}
+			if !reflect.DeepEqual(problems, tt.wantProblems) {
+				t.Errorf("storage.inspect() problems = %q, want %q", problems, tt.wantProblems)
+			}
+		})
+	}
+}
+
+func Test_storage_searchEach(t *testing.T) {
+	data := []UserData{
+		{ID: "1", Tags: []string{"a"}},
+		{ID: "2", Tags: []string{"b"}},
+		{ID: "3", Tags: []string{"a", "b"}},
+		{ID: "4", Tags: []string{"a"}},
+	}
+	errStop := errors.New("stop")
+
+	for _, format := range StoreFormats {
+		t.Run(format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "data.out")
+			s := newStorageWithFormat(format)
+			if err := s.storeAndReplace(context.Background(), data, path); err != nil {
+				t.Fatalf("storage.storeAndReplace() error = %v", err)
+			}
+
+			search := func(stopAfter int, stopErr error) (ids []string, err error) {
+				err = s.searchEach(context.Background(), Filter{Query: AllTagsQuery([]string{"a"})}, path, func(user UserData) error {
+					ids = append(ids, user.ID)
+					if len(ids) == stopAfter {
+						return stopErr
+					}
+					return nil
+				})
+				return ids, err
+			}
+
+			ids, err := search(0, nil)
+			if err != nil || !reflect.DeepEqual(ids, []string{"1", "3", "4"}) {
+				t.Errorf("storage.searchEach() = %v, %v, want every match", ids, err)
+			}
+			ids, err = search(2, ErrStopSearch)
+			if err != nil || !reflect.DeepEqual(ids, []string{"1", "3"}) {
+				t.Errorf("storage.searchEach() = %v, %v, want to stop after 2 matches", ids, err)
+			}
+			ids, err = search(1, errStop)
+			if err != errStop || !reflect.DeepEqual(ids, []string{"1"}) {
+				t.Errorf("storage.searchEach() = %v, %v, want %v", ids, err, errStop)
+			}
+		})
+	}
+}
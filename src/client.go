@@ -0,0 +1,215 @@
+package src
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ClientOptions configures NewClient.
+type ClientOptions struct {
+	// HTTPClient sends the requests to the sources, a client with a 10 second
+	// timeout when nil.
+	HTTPClient *http.Client
+	// Retry is the retry policy of every request made to the sources, the zero
+	// policy makes a single attempt. See DefaultRetryPolicy.
+	Retry RetryPolicy
+	// Store is the storage format of the data files, one of StoreFormats.
+	// Empty picks the format from the extension of each path, CSV by default.
+	// Ignored when Storage is set.
+	Store string
+	// Storage reads and writes the data files, NewStorage(Store) when nil.
+	Storage Storage
+	// MaxBodySize is the largest source response read in bytes, larger
+	// responses fail the fetch. 0 uses DefaultMaxBodySize and a negative
+	// size disables the limit.
+	MaxBodySize int64
+	// Sources are fetched in priority order, DefaultSources when empty.
+	Sources []Source
+}
+
+// Client fetches users from its sources and stores and searches data files.
+// Clients share no state, several configurations can be used side by side.
+// A Client is safe for concurrent use.
+type Client struct {
+	uc      usecaseIface
+	sources []Source
+}
+
+// NewClient returns a Client configured by opt.
+func NewClient(opt ClientOptions) (*Client, error) {
+	storage := opt.Storage
+	if storage == nil {
+		var err error
+		if storage, err = NewStorage(opt.Store); err != nil {
+			return nil, err
+		}
+	}
+	httpClient := opt.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Timeout: 10 * time.Second,
+		}
+	}
+	maxBody := opt.MaxBodySize
+	if maxBody == 0 {
+		maxBody = DefaultMaxBodySize
+	}
+
+	u, err := newUsecaseWithStorage(httpClient, opt.Retry, maxBody, storage)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		uc:      u,
+		sources: sourcesOrDefault(opt.Sources),
+	}, nil
+}
+
+var (
+	defaultMu     sync.Mutex
+	defaultClient *Client
+)
+
+// Default returns the Client behind the package level functions, the last
+// one set by SetDefault, Build or BuildWithRetry. A Client with
+// DefaultRetryPolicy is created when none was set.
+func Default() *Client {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultClient == nil {
+		c, err := NewClient(ClientOptions{Retry: DefaultRetryPolicy()})
+		if err != nil {
+			log.Fatal(err)
+		}
+		defaultClient = c
+	}
+	return defaultClient
+}
+
+// SetDefault makes c the Client behind the package level functions.
+func SetDefault(c *Client) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultClient = c
+}
+
+// Sources returns the sources c fetches from.
+func (c *Client) Sources() []Source {
+	return append([]Source(nil), c.sources...)
+}
+
+// WithSources returns a copy of c fetching from sources instead, or c itself
+// when no source is given.
+func (c *Client) WithSources(sources ...Source) *Client {
+	if len(sources) == 0 {
+		return c
+	}
+	return &Client{
+		uc:      c.uc,
+		sources: append([]Source(nil), sources...),
+	}
+}
+
+// Fetch requests the sources in priority order, falling back to the next one
+// when a source is down.
+func (c *Client) Fetch(ctx context.Context) (data []UserData, err error) {
+	return c.uc.GetSampleAPIResourceRedirect(ctx, c.sources)
+}
+
+// FetchFanOut queries all sources in parallel and returns the first valid
+// response instead of falling back source by source.
+func (c *Client) FetchFanOut(ctx context.Context) (data []UserData, err error) {
+	return c.uc.GetSampleAPIResourceFanOut(ctx, c.sources)
+}
+
+// FetchMerged fetches every source and combines their data, resolving
+// duplicated IDs with rule.
+func (c *Client) FetchMerged(ctx context.Context, rule MergeRule) (data []UserData, err error) {
+	return c.uc.GetSampleAPIResourceMerged(ctx, c.sources, rule)
+}
+
+// FetchAndStore fetches like Fetch and writes the users to the data file at
+// path as they are decoded from the response, so they are never all held in
+// memory. The previous file is kept when the fetch fails midway or ctx is
+// cancelled.
+func (c *Client) FetchAndStore(ctx context.Context, path string) error {
+	return c.uc.FetchAndStoreUserData(ctx, c.sources, path)
+}
+
+// Store replaces the data file at path with data.
+func (c *Client) Store(ctx context.Context, data []UserData, path string) error {
+	return c.uc.StoreAndReplaceUserDataToCSV(ctx, data, path)
+}
+
+// Upsert merges data into the stored users instead of replacing them: users
+// are matched by ID, new IDs are appended and stored users missing from data
+// are kept, deleted or deactivated according to policy.
+func (c *Client) Upsert(ctx context.Context, data []UserData, path string, policy TombstonePolicy) (result UpsertResult, err error) {
+	return c.uc.UpsertUserData(ctx, data, path, policy)
+}
+
+// Search returns the users having every tag of tags.
+func (c *Client) Search(ctx context.Context, tags []string, path string) (data []UserData, err error) {
+	return c.uc.SearchUserWithTags(ctx, tags, path)
+}
+
+// SearchQuery returns the users whose tags match a boolean expression such as
+// "sed AND (quis OR NOT dolor)", see ParseQuery.
+func (c *Client) SearchQuery(ctx context.Context, query string, path string) (data []UserData, err error) {
+	q, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return c.uc.SearchUserWithFilter(ctx, Filter{Query: q}, path)
+}
+
+// SearchFilter returns the users passing every condition of filter.
+func (c *Client) SearchFilter(ctx context.Context, filter Filter, path string) (data []UserData, err error) {
+	return c.uc.SearchUserWithFilter(ctx, filter, path)
+}
+
+// SearchEach is the streaming form of SearchFilter: fn is called with every
+// matching user as soon as it is read. Returning ErrStopSearch from fn ends
+// the search early without error, any other error from fn is returned.
+func (c *Client) SearchEach(ctx context.Context, filter Filter, path string, fn func(user UserData) error) error {
+	return c.uc.SearchUserEach(ctx, filter, path, fn)
+}
+
+// Lookup returns the first user stored with id in the data file at path,
+// found is false when there is none. The kv storage reads the user directly,
+// the other formats are scanned up to the first match.
+func (c *Client) Lookup(ctx context.Context, id string, path string) (user UserData, found bool, err error) {
+	return c.uc.LookupUser(ctx, id, path)
+}
+
+// Inspect reads the whole data file at path and reports row counts, tag usage
+// and every malformed row.
+func (c *Client) Inspect(ctx context.Context, path string) (report Report, err error) {
+	return c.uc.InspectCSV(ctx, path)
+}
+
+// Migrate rewrites the file at path in the current schema version, migrated
+// is false when the file was already up to date.
+func (c *Client) Migrate(ctx context.Context, path string) (migrated bool, err error) {
+	return c.uc.MigrateCSV(ctx, path)
+}
+
+// Snapshot copies the data file at path into SnapshotDir(path) as a snapshot
+// named after the current time, then removes the snapshots beyond opt.Keep.
+func (c *Client) Snapshot(ctx context.Context, path string, opt SnapshotOptions) (snapshot Snapshot, err error) {
+	return c.uc.SnapshotCSV(ctx, path, opt)
+}
+
+// Snapshots returns the snapshots of the data file at path, oldest first.
+func (c *Client) Snapshots(ctx context.Context, path string) (list []Snapshot, err error) {
+	return c.uc.ListSnapshots(ctx, path)
+}
+
+// Diff reports the users added, removed and modified between the data files
+// from and to, usually two snapshots.
+func (c *Client) Diff(ctx context.Context, from, to string) (diff Diff, err error) {
+	return c.uc.DiffCSV(ctx, from, to)
+}
@@ -0,0 +1,176 @@
+package src
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestNewClient(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	httpClient := &http.Client{Timeout: time.Second}
+	storage := NewMockstorageIface(mockCtrl)
+	sources := []Source{{URL: "http://127.0.0.1/users.json"}}
+
+	tests := []struct {
+		name        string
+		opt         ClientOptions
+		wantUC      usecaseIface
+		wantSources []Source
+		wantErr     bool
+	}{
+		{
+			name: "test1_defaults",
+			wantUC: &usecase{
+				api: &apiFetcher{
+					httpClient: &http.Client{Timeout: 10 * time.Second},
+					maxBody:    DefaultMaxBodySize,
+				},
+				storage: newStorage(),
+			},
+			wantSources: DefaultSources(),
+		},
+		{
+			name: "test2_options",
+			opt: ClientOptions{
+				HTTPClient:  httpClient,
+				Retry:       DefaultRetryPolicy(),
+				Store:       StoreJSON,
+				MaxBodySize: -1,
+				Sources:     sources,
+			},
+			wantUC: &usecase{
+				api: &apiFetcher{
+					httpClient: httpClient,
+					retry:      DefaultRetryPolicy(),
+					maxBody:    -1,
+				},
+				storage: newStorageWithFormat(StoreJSON),
+			},
+			wantSources: sources,
+		},
+		{
+			name:    "test3_bad_store",
+			opt:     ClientOptions{Store: "xml"},
+			wantErr: true,
+		},
+		{
+			name: "test4_storage",
+			opt: ClientOptions{
+				Store:   "xml",
+				Storage: storage,
+			},
+			wantUC: &usecase{
+				api: &apiFetcher{
+					httpClient: &http.Client{Timeout: 10 * time.Second},
+					maxBody:    DefaultMaxBodySize,
+				},
+				storage: storage,
+			},
+			wantSources: DefaultSources(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewClient(tt.opt)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewClient() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got.uc, tt.wantUC) {
+				t.Errorf("NewClient() usecase = %v, want %v", got.uc, tt.wantUC)
+			}
+			if !reflect.DeepEqual(got.Sources(), tt.wantSources) {
+				t.Errorf("NewClient() sources = %v, want %v", got.Sources(), tt.wantSources)
+			}
+		})
+	}
+}
+
+func TestClient_WithSources(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	client, mock := newMockClient(mockCtrl)
+	sources := []Source{{URL: "http://127.0.0.1/users.json"}}
+	want := []UserData{{ID: "1"}}
+	mock.EXPECT().GetSampleAPIResourceRedirect(gomock.Any(), sources).Return(want, nil).Times(1)
+	mock.EXPECT().GetSampleAPIResourceRedirect(gomock.Any(), DefaultSources()).Return(nil, nil).Times(1)
+
+	got, err := client.WithSources(sources...).Fetch(context.Background())
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Client.WithSources().Fetch() = %v, %v, want %v", got, err, want)
+	}
+	// The original client keeps its sources.
+	if _, err := client.Fetch(context.Background()); err != nil {
+		t.Errorf("Client.Fetch() error = %v", err)
+	}
+	if client.WithSources() != client {
+		t.Errorf("Client.WithSources() without sources should return the client itself")
+	}
+}
+
+func TestClient_storage(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	storage := NewMockstorageIface(mockCtrl)
+	want := []UserData{{ID: "1", Tags: []string{"a"}}}
+	storage.EXPECT().search(gomock.Any(), []string{"a"}, "data.csv").Return(want, nil).Times(1)
+
+	client, err := NewClient(ClientOptions{Storage: storage})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := client.Search(context.Background(), []string{"a"}, "data.csv")
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Client.Search() = %v, %v, want %v", got, err, want)
+	}
+}
+
+// Two clients with their own sources and storage formats work side by side.
+func TestClient_independent(t *testing.T) {
+	serve := func(body string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}))
+	}
+	ts1 := serve(`[{"_id": "1", "tags": ["a"]}]`)
+	defer ts1.Close()
+	ts2 := serve(`[{"_id": "2", "tags": ["a"]}]`)
+	defer ts2.Close()
+
+	dir := t.TempDir()
+	ctx := context.Background()
+	clients := []struct {
+		opt    ClientOptions
+		path   string
+		wantID string
+	}{
+		{opt: ClientOptions{Sources: []Source{{URL: ts1.URL}}}, path: filepath.Join(dir, "one.csv"), wantID: "1"},
+		{opt: ClientOptions{Sources: []Source{{URL: ts2.URL}}, Store: StoreJSON}, path: filepath.Join(dir, "two.data"), wantID: "2"},
+	}
+	for _, v := range clients {
+		client, err := NewClient(v.opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := client.FetchAndStore(ctx, v.path); err != nil {
+			t.Fatalf("Client.FetchAndStore() error = %v", err)
+		}
+		got, err := client.Search(ctx, []string{"a"}, v.path)
+		if err != nil || len(got) != 1 || got[0].ID != v.wantID {
+			t.Errorf("Client.Search() = %v, %v, want user %s", got, err, v.wantID)
+		}
+	}
+}
@@ -1,27 +1,219 @@
 package src
 
-import "context"
+import (
+	"context"
+	"log"
+)
 
 const (
 	APILink1 = "https://run.mocky.io/v3/03d2a7bd-f12f-4275-9e9a-84e41f9c2aae"
 	APILink2 = "https://run.mocky.io/v3/aab281fe-3dbb-4d91-a863-a96e6bf083d7"
 )
 
+// Build sets the Client behind the package level functions to one with
+// DefaultRetryPolicy, see Default.
 func Build() {
-	newUsecase()
+	BuildWithRetry(DefaultRetryPolicy())
+}
+
+// BuildWithRetry is like Build but uses the given retry policy for every
+// request made to the sources instead of DefaultRetryPolicy. Use NewClient
+// and SetDefault for the other options.
+func BuildWithRetry(policy RetryPolicy) {
+	c, err := NewClient(ClientOptions{Retry: policy})
+	if err != nil {
+		log.Fatal(err)
+	}
+	SetDefault(c)
+}
+
+// GetFromSource fetches from the given sources in priority order, falling
+// back to the next one when a source is down. DefaultSources is used when no
+// source is given.
+func GetFromSource(sources ...Source) (data []UserData, err error) {
+	return GetFromSourceContext(context.Background(), sources...)
+}
+
+// GetFromSourceContext is GetFromSource with a context, cancelling ctx stops
+// the request in flight.
+func GetFromSourceContext(ctx context.Context, sources ...Source) (data []UserData, err error) {
+	return Default().WithSources(sources...).Fetch(ctx)
+}
+
+// GetFromSourceFanOut queries all sources in parallel and returns the
+// first valid response instead of falling back source by source.
+func GetFromSourceFanOut(sources ...Source) (data []UserData, err error) {
+	return GetFromSourceFanOutContext(context.Background(), sources...)
+}
+
+// GetFromSourceFanOutContext is GetFromSourceFanOut with a context.
+func GetFromSourceFanOutContext(ctx context.Context, sources ...Source) (data []UserData, err error) {
+	return Default().WithSources(sources...).FetchFanOut(ctx)
+}
+
+// GetFromSourceMerged fetches every source and combines their data,
+// resolving duplicated IDs with rule.
+func GetFromSourceMerged(rule MergeRule, sources ...Source) (data []UserData, err error) {
+	return GetFromSourceMergedContext(context.Background(), rule, sources...)
+}
+
+// GetFromSourceMergedContext is GetFromSourceMerged with a context.
+func GetFromSourceMergedContext(ctx context.Context, rule MergeRule, sources ...Source) (data []UserData, err error) {
+	return Default().WithSources(sources...).FetchMerged(ctx, rule)
 }
 
-func GetFromSource() (data []UserData, err error) {
-	return uc.GetSampleAPIResourceRedirect(context.Background(), []string{
-		APILink1,
-		APILink2,
-	})
+func sourcesOrDefault(sources []Source) []Source {
+	if len(sources) == 0 {
+		return DefaultSources()
+	}
+	return sources
 }
 
+// SetAndReplaceToCSV replaces the data file at path with data.
 func SetAndReplaceToCSV(data []UserData, path string) error {
-	return uc.StoreAndReplaceUserDataToCSV(context.Background(), data, path)
+	return SetAndReplaceToCSVContext(context.Background(), data, path)
+}
+
+// SetAndReplaceToCSVContext is SetAndReplaceToCSV with a context.
+func SetAndReplaceToCSVContext(ctx context.Context, data []UserData, path string) error {
+	return Default().Store(ctx, data, path)
+}
+
+// StoreFromSource fetches from the sources like GetFromSource and writes the
+// users to the data file at path as they are decoded from the response, so
+// they are never all held in memory. The previous file is kept when the fetch
+// fails midway.
+func StoreFromSource(path string, sources ...Source) error {
+	return StoreFromSourceContext(context.Background(), path, sources...)
+}
+
+// StoreFromSourceContext is StoreFromSource with a context. Cancelling ctx
+// stops the fetch in flight and keeps the previous file.
+func StoreFromSourceContext(ctx context.Context, path string, sources ...Source) error {
+	return Default().WithSources(sources...).FetchAndStore(ctx, path)
+}
+
+// UpsertToCSV merges data into the stored users instead of replacing them:
+// users are matched by ID, new IDs are appended and stored users missing from
+// data are kept, deleted or deactivated according to policy.
+func UpsertToCSV(data []UserData, path string, policy TombstonePolicy) (result UpsertResult, err error) {
+	return UpsertToCSVContext(context.Background(), data, path, policy)
+}
+
+// UpsertToCSVContext is UpsertToCSV with a context.
+func UpsertToCSVContext(ctx context.Context, data []UserData, path string, policy TombstonePolicy) (result UpsertResult, err error) {
+	return Default().Upsert(ctx, data, path, policy)
 }
 
+// SearchFromCSV returns the users having every tag of tags.
 func SearchFromCSV(tags []string, path string) (data []UserData, err error) {
-	return uc.SearchUserWithTags(context.Background(), tags, path)
+	return SearchFromCSVContext(context.Background(), tags, path)
+}
+
+// SearchFromCSVContext is SearchFromCSV with a context.
+func SearchFromCSVContext(ctx context.Context, tags []string, path string) (data []UserData, err error) {
+	return Default().Search(ctx, tags, path)
+}
+
+// SearchFromCSVWithQuery returns the users whose tags match a boolean
+// expression such as "sed AND (quis OR NOT dolor)", see ParseQuery.
+func SearchFromCSVWithQuery(query string, path string) (data []UserData, err error) {
+	return SearchFromCSVWithQueryContext(context.Background(), query, path)
+}
+
+// SearchFromCSVWithQueryContext is SearchFromCSVWithQuery with a context.
+func SearchFromCSVWithQueryContext(ctx context.Context, query string, path string) (data []UserData, err error) {
+	return Default().SearchQuery(ctx, query, path)
+}
+
+// SearchFromCSVWithFilter returns the users passing every condition of filter,
+// combining a tag query with active status and balance range checks.
+func SearchFromCSVWithFilter(filter Filter, path string) (data []UserData, err error) {
+	return SearchFromCSVWithFilterContext(context.Background(), filter, path)
+}
+
+// SearchFromCSVWithFilterContext is SearchFromCSVWithFilter with a context.
+func SearchFromCSVWithFilterContext(ctx context.Context, filter Filter, path string) (data []UserData, err error) {
+	return Default().SearchFilter(ctx, filter, path)
+}
+
+// SearchFromCSVEach is the streaming form of SearchFromCSVWithFilter: fn is
+// called with every matching user as soon as it is read instead of collecting
+// them. Returning ErrStopSearch from fn ends the search early without error,
+// any other error from fn is returned.
+func SearchFromCSVEach(filter Filter, path string, fn func(user UserData) error) (err error) {
+	return SearchFromCSVEachContext(context.Background(), filter, path, fn)
+}
+
+// SearchFromCSVEachContext is SearchFromCSVEach with a context.
+func SearchFromCSVEachContext(ctx context.Context, filter Filter, path string, fn func(user UserData) error) (err error) {
+	return Default().SearchEach(ctx, filter, path, fn)
+}
+
+// LookupUser returns the first user stored with id in the data file at path,
+// found is false when there is none.
+func LookupUser(id string, path string) (user UserData, found bool, err error) {
+	return LookupUserContext(context.Background(), id, path)
+}
+
+// LookupUserContext is LookupUser with a context.
+func LookupUserContext(ctx context.Context, id string, path string) (user UserData, found bool, err error) {
+	return Default().Lookup(ctx, id, path)
+}
+
+// InspectCSV reads the whole CSV file at path and reports row counts, tag
+// usage and every malformed row. ErrMissingFile is wrapped when the file
+// does not exist.
+func InspectCSV(path string) (report Report, err error) {
+	return InspectCSVContext(context.Background(), path)
+}
+
+// InspectCSVContext is InspectCSV with a context.
+func InspectCSVContext(ctx context.Context, path string) (report Report, err error) {
+	return Default().Inspect(ctx, path)
+}
+
+// MigrateCSV rewrites the file at path in the current schema version,
+// SchemaVersion. Older files stay readable without migrating, migrated is
+// false when the file was already up to date.
+func MigrateCSV(path string) (migrated bool, err error) {
+	return MigrateCSVContext(context.Background(), path)
+}
+
+// MigrateCSVContext is MigrateCSV with a context.
+func MigrateCSVContext(ctx context.Context, path string) (migrated bool, err error) {
+	return Default().Migrate(ctx, path)
+}
+
+// SnapshotCSV copies the data file at path into SnapshotDir(path) as a
+// snapshot named after the current time, then removes the snapshots beyond
+// opt.Keep.
+func SnapshotCSV(path string, opt SnapshotOptions) (snapshot Snapshot, err error) {
+	return SnapshotCSVContext(context.Background(), path, opt)
+}
+
+// SnapshotCSVContext is SnapshotCSV with a context.
+func SnapshotCSVContext(ctx context.Context, path string, opt SnapshotOptions) (snapshot Snapshot, err error) {
+	return Default().Snapshot(ctx, path, opt)
+}
+
+// ListSnapshots returns the snapshots of the data file at path, oldest first.
+func ListSnapshots(path string) (list []Snapshot, err error) {
+	return ListSnapshotsContext(context.Background(), path)
+}
+
+// ListSnapshotsContext is ListSnapshots with a context.
+func ListSnapshotsContext(ctx context.Context, path string) (list []Snapshot, err error) {
+	return Default().Snapshots(ctx, path)
+}
+
+// DiffCSV reports the users added, removed and modified between the data
+// files from and to, usually two snapshots.
+func DiffCSV(from, to string) (diff Diff, err error) {
+	return DiffCSVContext(context.Background(), from, to)
+}
+
+// DiffCSVContext is DiffCSV with a context.
+func DiffCSVContext(ctx context.Context, from, to string) (diff Diff, err error) {
+	return Default().Diff(ctx, from, to)
 }
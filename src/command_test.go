@@ -1,9 +1,11 @@
 package src
 
 import (
+	"context"
 	"errors"
 	"reflect"
 	"testing"
+	"time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -28,10 +30,7 @@ func TestGetFromSource(t *testing.T) {
 			wantErr: false,
 			mock: func() {
 				mock := newMockUC(mockCtrl)
-				mock.EXPECT().GetSampleAPIResourceRedirect(gomock.Any(), []string{
-					APILink1,
-					APILink2,
-				}).Return([]UserData{
+				mock.EXPECT().GetSampleAPIResourceRedirect(gomock.Any(), DefaultSources()).Return([]UserData{
 					{
 						ID: "1",
 					},
@@ -43,10 +42,7 @@ func TestGetFromSource(t *testing.T) {
 			wantErr: true,
 			mock: func() {
 				mock := newMockUC(mockCtrl)
-				mock.EXPECT().GetSampleAPIResourceRedirect(gomock.Any(), []string{
-					APILink1,
-					APILink2,
-				}).Return(nil, errors.New("err")).Times(1)
+				mock.EXPECT().GetSampleAPIResourceRedirect(gomock.Any(), DefaultSources()).Return(nil, errors.New("err")).Times(1)
 			},
 		},
 	}
@@ -67,6 +63,112 @@ func TestGetFromSource(t *testing.T) {
 	}
 }
 
+func TestGetFromSourceFanOut(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	tests := []struct {
+		name     string
+		wantData []UserData
+		wantErr  bool
+		mock     func()
+	}{
+		{
+			name: "test1_success",
+			wantData: []UserData{
+				{
+					ID: "1",
+				},
+			},
+			wantErr: false,
+			mock: func() {
+				mock := newMockUC(mockCtrl)
+				mock.EXPECT().GetSampleAPIResourceFanOut(gomock.Any(), DefaultSources()).Return([]UserData{
+					{
+						ID: "1",
+					},
+				}, nil).Times(1)
+			},
+		},
+		{
+			name:    "test2_fail",
+			wantErr: true,
+			mock: func() {
+				mock := newMockUC(mockCtrl)
+				mock.EXPECT().GetSampleAPIResourceFanOut(gomock.Any(), DefaultSources()).Return(nil, errors.New("err")).Times(1)
+			},
+		},
+	}
+	for _, tt := range tests {
+		if tt.mock != nil {
+			tt.mock()
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			gotData, err := GetFromSourceFanOut()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetFromSourceFanOut() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotData, tt.wantData) {
+				t.Errorf("GetFromSourceFanOut() = %v, want %v", gotData, tt.wantData)
+			}
+		})
+	}
+}
+
+func TestGetFromSourceMerged(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	tests := []struct {
+		name     string
+		wantData []UserData
+		wantErr  bool
+		mock     func()
+	}{
+		{
+			name: "test1_success",
+			wantData: []UserData{
+				{
+					ID: "1",
+				},
+			},
+			wantErr: false,
+			mock: func() {
+				mock := newMockUC(mockCtrl)
+				mock.EXPECT().GetSampleAPIResourceMerged(gomock.Any(), DefaultSources(), MergeLastWins).Return([]UserData{
+					{
+						ID: "1",
+					},
+				}, nil).Times(1)
+			},
+		},
+		{
+			name:    "test2_fail",
+			wantErr: true,
+			mock: func() {
+				mock := newMockUC(mockCtrl)
+				mock.EXPECT().GetSampleAPIResourceMerged(gomock.Any(), DefaultSources(), MergeLastWins).Return(nil, errors.New("err")).Times(1)
+			},
+		},
+	}
+	for _, tt := range tests {
+		if tt.mock != nil {
+			tt.mock()
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			gotData, err := GetFromSourceMerged(MergeLastWins)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetFromSourceMerged() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotData, tt.wantData) {
+				t.Errorf("GetFromSourceMerged() = %v, want %v", gotData, tt.wantData)
+			}
+		})
+	}
+}
+
 func TestSetAndReplaceToCSV(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -199,3 +301,428 @@ func TestSearchFromCSV(t *testing.T) {
 		})
 	}
 }
+
+func TestSearchFromCSVWithFilter(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	type args struct {
+		filter Filter
+		path   string
+	}
+	tests := []struct {
+		name     string
+		args     args
+		wantData []UserData
+		wantErr  bool
+		mock     func()
+	}{
+		{
+			name: "test1_success",
+			args: args{
+				filter: Filter{Query: AllTagsQuery([]string{"a", "b"})},
+				path:   "data.csv",
+			},
+			wantErr: false,
+			wantData: []UserData{
+				{
+					ID: "1",
+				},
+			},
+			mock: func() {
+				mock := newMockUC(mockCtrl)
+				mock.EXPECT().SearchUserWithFilter(gomock.Any(), Filter{Query: AllTagsQuery([]string{"a", "b"})}, "data.csv").Return([]UserData{
+					{
+						ID: "1",
+					},
+				}, nil).Times(1)
+			},
+		},
+		{
+			name: "test2_fail",
+			args: args{
+				filter: Filter{Query: AllTagsQuery([]string{"a", "b"})},
+				path:   "data.csv",
+			},
+			wantErr: true,
+			mock: func() {
+				mock := newMockUC(mockCtrl)
+				mock.EXPECT().SearchUserWithFilter(gomock.Any(), Filter{Query: AllTagsQuery([]string{"a", "b"})}, "data.csv").Return(nil, errors.New("err")).Times(1)
+			},
+		},
+	}
+	for _, tt := range tests {
+		if tt.mock != nil {
+			tt.mock()
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			gotData, err := SearchFromCSVWithFilter(tt.args.filter, tt.args.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SearchFromCSVWithFilter() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotData, tt.wantData) {
+				t.Errorf("SearchFromCSVWithFilter() = %v, want %v", gotData, tt.wantData)
+			}
+		})
+	}
+}
+
+func TestSearchFromCSVWithQuery(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	type args struct {
+		query string
+		path  string
+	}
+	tests := []struct {
+		name     string
+		args     args
+		wantData []UserData
+		wantErr  bool
+		mock     func()
+	}{
+		{
+			name: "test1_success",
+			args: args{
+				query: "a OR b",
+				path:  "data.csv",
+			},
+			wantErr: false,
+			wantData: []UserData{
+				{
+					ID: "1",
+				},
+			},
+			mock: func() {
+				mock := newMockUC(mockCtrl)
+				mock.EXPECT().SearchUserWithFilter(gomock.Any(), Filter{Query: orQuery{left: tagQuery{tag: "a"}, right: tagQuery{tag: "b"}}}, "data.csv").Return([]UserData{
+					{
+						ID: "1",
+					},
+				}, nil).Times(1)
+			},
+		},
+		{
+			name: "test2_fail_parse",
+			args: args{
+				query: "a OR",
+				path:  "data.csv",
+			},
+			wantErr: true,
+		},
+		{
+			name: "test3_fail",
+			args: args{
+				query: "a OR b",
+				path:  "data.csv",
+			},
+			wantErr: true,
+			mock: func() {
+				mock := newMockUC(mockCtrl)
+				mock.EXPECT().SearchUserWithFilter(gomock.Any(), Filter{Query: orQuery{left: tagQuery{tag: "a"}, right: tagQuery{tag: "b"}}}, "data.csv").Return(nil, errors.New("err")).Times(1)
+			},
+		},
+	}
+	for _, tt := range tests {
+		if tt.mock != nil {
+			tt.mock()
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			gotData, err := SearchFromCSVWithQuery(tt.args.query, tt.args.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SearchFromCSVWithQuery() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotData, tt.wantData) {
+				t.Errorf("SearchFromCSVWithQuery() = %v, want %v", gotData, tt.wantData)
+			}
+		})
+	}
+}
+
+func TestInspectCSV(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	tests := []struct {
+		name       string
+		path       string
+		wantReport Report
+		wantErr    bool
+		mock       func()
+	}{
+		{
+			name: "test1_success",
+			path: "data.csv",
+			wantReport: Report{
+				Rows: 1,
+			},
+			mock: func() {
+				mock := newMockUC(mockCtrl)
+				mock.EXPECT().InspectCSV(gomock.Any(), "data.csv").Return(Report{
+					Rows: 1,
+				}, nil).Times(1)
+			},
+		},
+		{
+			name:    "test2_fail",
+			path:    "data.csv",
+			wantErr: true,
+			mock: func() {
+				mock := newMockUC(mockCtrl)
+				mock.EXPECT().InspectCSV(gomock.Any(), "data.csv").Return(Report{}, errors.New("err")).Times(1)
+			},
+		},
+	}
+	for _, tt := range tests {
+		if tt.mock != nil {
+			tt.mock()
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			gotReport, err := InspectCSV(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("InspectCSV() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotReport, tt.wantReport) {
+				t.Errorf("InspectCSV() = %v, want %v", gotReport, tt.wantReport)
+			}
+		})
+	}
+}
+
+func TestMigrateCSV(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	tests := []struct {
+		name         string
+		path         string
+		wantMigrated bool
+		wantErr      bool
+		mock         func()
+	}{
+		{
+			name:         "test1_success",
+			path:         "data.csv",
+			wantMigrated: true,
+			mock: func() {
+				mock := newMockUC(mockCtrl)
+				mock.EXPECT().MigrateCSV(gomock.Any(), "data.csv").Return(true, nil).Times(1)
+			},
+		},
+		{
+			name:    "test2_fail",
+			path:    "data.csv",
+			wantErr: true,
+			mock: func() {
+				mock := newMockUC(mockCtrl)
+				mock.EXPECT().MigrateCSV(gomock.Any(), "data.csv").Return(false, errors.New("err")).Times(1)
+			},
+		},
+	}
+	for _, tt := range tests {
+		if tt.mock != nil {
+			tt.mock()
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			gotMigrated, err := MigrateCSV(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MigrateCSV() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotMigrated != tt.wantMigrated {
+				t.Errorf("MigrateCSV() = %v, want %v", gotMigrated, tt.wantMigrated)
+			}
+		})
+	}
+}
+
+func TestUpsertToCSV(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	tests := []struct {
+		name       string
+		data       []UserData
+		path       string
+		policy     TombstonePolicy
+		wantResult UpsertResult
+		wantErr    bool
+		mock       func()
+	}{
+		{
+			name:       "test1_success",
+			data:       []UserData{{ID: "1"}},
+			path:       "data.csv",
+			policy:     TombstoneDeactivate,
+			wantResult: UpsertResult{Updated: 1},
+			mock: func() {
+				mock := newMockUC(mockCtrl)
+				mock.EXPECT().UpsertUserData(gomock.Any(), []UserData{{ID: "1"}}, "data.csv", TombstoneDeactivate).Return(UpsertResult{Updated: 1}, nil).Times(1)
+			},
+		},
+		{
+			name:    "test2_fail",
+			path:    "data.csv",
+			wantErr: true,
+			mock: func() {
+				mock := newMockUC(mockCtrl)
+				mock.EXPECT().UpsertUserData(gomock.Any(), nil, "data.csv", TombstoneKeep).Return(UpsertResult{}, errors.New("err")).Times(1)
+			},
+		},
+	}
+	for _, tt := range tests {
+		if tt.mock != nil {
+			tt.mock()
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			gotResult, err := UpsertToCSV(tt.data, tt.path, tt.policy)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UpsertToCSV() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotResult != tt.wantResult {
+				t.Errorf("UpsertToCSV() = %v, want %v", gotResult, tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestDiffCSV(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	tests := []struct {
+		name     string
+		wantDiff Diff
+		wantErr  bool
+		mock     func()
+	}{
+		{
+			name:     "test1_success",
+			wantDiff: Diff{Removed: []UserData{{ID: "1"}}},
+			mock: func() {
+				mock := newMockUC(mockCtrl)
+				mock.EXPECT().DiffCSV(gomock.Any(), "a.csv", "b.csv").Return(Diff{Removed: []UserData{{ID: "1"}}}, nil).Times(1)
+			},
+		},
+		{
+			name:    "test2_fail",
+			wantErr: true,
+			mock: func() {
+				mock := newMockUC(mockCtrl)
+				mock.EXPECT().DiffCSV(gomock.Any(), "a.csv", "b.csv").Return(Diff{}, errors.New("err")).Times(1)
+			},
+		},
+	}
+	for _, tt := range tests {
+		if tt.mock != nil {
+			tt.mock()
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			gotDiff, err := DiffCSV("a.csv", "b.csv")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DiffCSV() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotDiff, tt.wantDiff) {
+				t.Errorf("DiffCSV() = %v, want %v", gotDiff, tt.wantDiff)
+			}
+		})
+	}
+}
+
+func TestListSnapshots(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	want := []Snapshot{{Path: "data.csv.snapshots/20240131T093000.000000000Z.csv"}}
+	mock := newMockUC(mockCtrl)
+	mock.EXPECT().ListSnapshots(gomock.Any(), "data.csv").Return(want, nil).Times(1)
+	got, err := ListSnapshots("data.csv")
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ListSnapshots() = %v, %v, want %v", got, err, want)
+	}
+}
+
+func TestSearchFromCSVEach(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mock := newMockUC(mockCtrl)
+	mock.EXPECT().SearchUserEach(gomock.Any(), Filter{}, "data.csv", gomock.Any()).Return(ErrMissingFile).Times(1)
+	err := SearchFromCSVEach(Filter{}, "data.csv", func(user UserData) error {
+		return nil
+	})
+	if err != ErrMissingFile {
+		t.Errorf("SearchFromCSVEach() error = %v, want %v", err, ErrMissingFile)
+	}
+}
+
+func TestLookupUser(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mock := newMockUC(mockCtrl)
+	mock.EXPECT().LookupUser(gomock.Any(), "1", "data.csv").Return(UserData{}, false, ErrMissingFile).Times(1)
+	_, found, err := LookupUser("1", "data.csv")
+	if found || err != ErrMissingFile {
+		t.Errorf("LookupUser() = %v, %v, want %v", found, err, ErrMissingFile)
+	}
+}
+
+func TestStoreFromSource(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mock := newMockUC(mockCtrl)
+	mock.EXPECT().FetchAndStoreUserData(gomock.Any(), DefaultSources(), "data.csv").Return(nil).Times(1)
+	if err := StoreFromSource("data.csv"); err != nil {
+		t.Errorf("StoreFromSource() error = %v", err)
+	}
+}
+
+func TestStoreFromSourceContext(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	mock := newMockUC(mockCtrl)
+	mock.EXPECT().FetchAndStoreUserData(ctx, DefaultSources(), "data.csv").Return(context.Canceled).Times(1)
+	if err := StoreFromSourceContext(ctx, "data.csv"); err != context.Canceled {
+		t.Errorf("StoreFromSourceContext() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestSearchFromCSVContext(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	want := []UserData{{ID: "1", Tags: []string{"a"}}}
+	mock := newMockUC(mockCtrl)
+	mock.EXPECT().SearchUserWithTags(ctx, []string{"a"}, "data.csv").Return(want, nil).Times(1)
+	got, err := SearchFromCSVContext(ctx, []string{"a"}, "data.csv")
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("SearchFromCSVContext() = %v, %v, want %v", got, err, want)
+	}
+}
+
+func TestSetAndReplaceToCSVContext(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	data := []UserData{{ID: "1"}}
+	mock := newMockUC(mockCtrl)
+	mock.EXPECT().StoreAndReplaceUserDataToCSV(ctx, data, "data.csv").Return(context.Canceled).Times(1)
+	if err := SetAndReplaceToCSVContext(ctx, data, "data.csv"); err != context.Canceled {
+		t.Errorf("SetAndReplaceToCSVContext() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,92 @@
+package src
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compressions of the data files, picked from a ".gz" or ".zst" extension
+// after the one of the storage format, e.g. data.csv.gz.
+const (
+	CompressGzip = "gzip"
+	CompressZstd = "zstd"
+)
+
+// Compressions lists every compression in the order shown to users.
+var Compressions = []string{CompressGzip, CompressZstd}
+
+var compressionExts = map[string]string{
+	CompressGzip: ".gz",
+	CompressZstd: ".zst",
+}
+
+// ParseCompression validates a compression name. An empty name is accepted
+// and means no compression.
+func ParseCompression(s string) (string, error) {
+	compression := strings.ToLower(strings.TrimSpace(s))
+	if compression == "" {
+		return "", nil
+	}
+	for _, v := range Compressions {
+		if v == compression {
+			return compression, nil
+		}
+	}
+	return "", fmt.Errorf("unknown compression %q, expected one of %s", s, strings.Join(Compressions, ", "))
+}
+
+// compressionFromPath returns the compression picked by the extension of
+// path, or "" for none, along with path without that extension.
+func compressionFromPath(path string) (compression, base string) {
+	ext := strings.ToLower(filepath.Ext(path))
+	for k, v := range compressionExts {
+		if ext == v {
+			return k, path[:len(path)-len(ext)]
+		}
+	}
+	return "", path
+}
+
+// decompress returns r decompressed according to the extension of path, or r
+// itself when path is not compressed. Closing the result does not close r.
+func decompress(r io.Reader, path string) (io.ReadCloser, error) {
+	compression, _ := compressionFromPath(path)
+	switch compression {
+	case CompressGzip:
+		return gzip.NewReader(r)
+	case CompressZstd:
+		dec, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	}
+	return io.NopCloser(r), nil
+}
+
+// compress returns a writer compressing into w according to the extension of
+// path, or w itself when path is not compressed. Close flushes the compressed
+// stream but does not close w.
+func compress(w io.Writer, path string) (io.WriteCloser, error) {
+	compression, _ := compressionFromPath(path)
+	switch compression {
+	case CompressGzip:
+		return gzip.NewWriter(w), nil
+	case CompressZstd:
+		return zstd.NewWriter(w)
+	}
+	return nopWriteCloser{w}, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,156 @@
+package src
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCompression(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "test1_empty",
+			s:    "",
+			want: "",
+		},
+		{
+			name: "test2_case_insensitive",
+			s:    " ZSTD ",
+			want: CompressZstd,
+		},
+		{
+			name:    "test3_unknown",
+			s:       "bzip2",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCompression(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseCompression() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseCompression() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_compressionFromPath(t *testing.T) {
+	tests := []struct {
+		path            string
+		wantCompression string
+		wantBase        string
+	}{
+		{path: "data.csv", wantCompression: "", wantBase: "data.csv"},
+		{path: "dir/data.csv.GZ", wantCompression: CompressGzip, wantBase: "dir/data.csv"},
+		{path: "data.ndjson.zst", wantCompression: CompressZstd, wantBase: "data.ndjson"},
+	}
+	for _, tt := range tests {
+		compression, base := compressionFromPath(tt.path)
+		if compression != tt.wantCompression || base != tt.wantBase {
+			t.Errorf("compressionFromPath(%q) = %q, %q, want %q, %q", tt.path, compression, base, tt.wantCompression, tt.wantBase)
+		}
+	}
+}
+
+func Test_storage_compressed(t *testing.T) {
+	data := []UserData{
+		{ID: "1", ActiveStatus: true, Balance: "$1,000.00", Tags: []string{"a", "b"}},
+		{ID: "2", Balance: "$2.50", Tags: []string{"b"}},
+		{ID: "3", ActiveStatus: true, Balance: "$0.00", Tags: []string{"a"}},
+	}
+
+	magic := map[string][]byte{
+		".gz":  {0x1f, 0x8b},
+		".zst": {0x28, 0xb5, 0x2f, 0xfd},
+	}
+
+	for _, name := range []string{"data.csv.gz", "data.csv.zst", "data.json.gz", "data.ndjson.zst"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			s := newStorage()
+			ctx := context.Background()
+
+			if err := s.storeAndReplace(ctx, data, path); err != nil {
+				t.Fatalf("storage.storeAndReplace() error = %v", err)
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.HasPrefix(content, magic[filepath.Ext(name)]) {
+				t.Errorf("storage.storeAndReplace() did not compress %s", name)
+			}
+			if _, err := os.Stat(csvIndexPath(path)); !os.IsNotExist(err) {
+				t.Errorf("storage.storeAndReplace() wrote an index for %s", name)
+			}
+
+			got, err := s.search(ctx, []string{"a"}, path)
+			if err != nil {
+				t.Fatalf("storage.search() error = %v", err)
+			}
+			if want := []UserData{data[0], data[2]}; !reflect.DeepEqual(got, want) {
+				t.Errorf("storage.search() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func Test_storage_compressed_unsupported(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.db.gz")
+	s := newStorage()
+	err := s.storeAndReplace(context.Background(), []UserData{{ID: "1"}}, path)
+	if err == nil || !strings.Contains(err.Error(), "cannot be gzip compressed") {
+		t.Errorf("storage.storeAndReplace() error = %v, want a compression error", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("storage.storeAndReplace() left %s behind", path)
+	}
+}
+
+func Test_storage_snapshot_compressed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.csv")
+	s := newStorage()
+	ctx := context.Background()
+	at := time.Date(2024, 1, 31, 9, 30, 0, 0, time.UTC)
+
+	if err := s.storeAndReplace(ctx, []UserData{{ID: "1", Balance: "$1.00"}}, path); err != nil {
+		t.Fatalf("storage.storeAndReplace() error = %v", err)
+	}
+	snapshot, err := s.snapshot(ctx, path, at, SnapshotOptions{Compression: CompressZstd})
+	if err != nil {
+		t.Fatalf("storage.snapshot() error = %v", err)
+	}
+	if want := filepath.Join(SnapshotDir(path), "20240131T093000.000000000Z.csv.zst"); snapshot.Path != want {
+		t.Errorf("storage.snapshot() path = %s, want %s", snapshot.Path, want)
+	}
+
+	diff, err := s.diff(ctx, snapshot.Path, path)
+	if err != nil {
+		t.Fatalf("storage.diff() error = %v", err)
+	}
+	if !diff.Empty() {
+		t.Errorf("storage.diff() = %+v, want no difference", diff)
+	}
+
+	kv := filepath.Join(t.TempDir(), "data.db")
+	if err := s.storeAndReplace(ctx, nil, kv); err != nil {
+		t.Fatalf("storage.storeAndReplace() error = %v", err)
+	}
+	if _, err := s.snapshot(ctx, kv, at, SnapshotOptions{Compression: CompressGzip}); err == nil {
+		t.Error("storage.snapshot() compressed a kv database")
+	}
+}
@@ -10,6 +10,7 @@ type (
 	csvWriterIface interface {
 		Write(record []string) error
 		Flush()
+		Error() error
 	}
 
 	csvReaderIface interface {
@@ -28,6 +29,10 @@ func (c *csvHandler) NewWriter(w io.Writer) csvWriterIface {
 	return csv.NewWriter(w)
 }
 
+// NewReader returns a reader accepting records of any length, the schema
+// marker record is shorter than the data records.
 func (c *csvHandler) NewReader(r io.Reader) csvReaderIface {
-	return csv.NewReader(r)
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	return reader
 }
@@ -0,0 +1,230 @@
+package src
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"hash"
+	"hash/crc32"
+	"io"
+	"path/filepath"
+	"sort"
+)
+
+// csvIndexVersion is bumped whenever the layout of csvIndex changes, older
+// index files are then ignored like stale ones.
+const csvIndexVersion = 1
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// csvIndex is the inverted tag index written next to a CSV file, at the CSV
+// path with ".idx" appended. It is only trusted while Size and CRC32C match
+// the CSV file, any other write to the CSV makes the index stale.
+type csvIndex struct {
+	Version int    `json:"version"`
+	Size    int64  `json:"size"`
+	CRC32C  uint32 `json:"crc32c"`
+	// FirstRow is the row number of the first data record, after the schema
+	// marker and header.
+	FirstRow int `json:"firstRow"`
+	// Tags maps each tag to the records carrying it, see postingList.
+	Tags map[string]postingList `json:"tags"`
+
+	// records counts the records added, last holds the last entry of each
+	// posting list to encode the next one against.
+	records int
+	last    map[string]indexEntry
+}
+
+// indexEntry locates a data record: pos counts the records from 0 and offset
+// is the byte offset the record starts at.
+type indexEntry struct {
+	pos    int
+	offset int64
+}
+
+// postingList holds the ascending entries of the records carrying a tag, as
+// pairs of uvarints: the difference of pos and of offset with the previous
+// entry, or with 0 for the first one. It is stored in base64.
+type postingList []byte
+
+func (l postingList) append(prev, e indexEntry) postingList {
+	var buf [2 * binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], uint64(e.pos-prev.pos))
+	n += binary.PutUvarint(buf[n:], uint64(e.offset-prev.offset))
+	return append(l, buf[:n]...)
+}
+
+// entries decodes the list, ok is false when it is malformed.
+func (l postingList) entries() (entries []indexEntry, ok bool) {
+	var e indexEntry
+	for len(l) > 0 {
+		pos, n := binary.Uvarint(l)
+		if n <= 0 {
+			return nil, false
+		}
+		offset, m := binary.Uvarint(l[n:])
+		if m <= 0 {
+			return nil, false
+		}
+		l = l[n+m:]
+		e.pos += int(pos)
+		e.offset += int64(offset)
+		entries = append(entries, e)
+	}
+	return entries, true
+}
+
+func newCSVIndex(firstRow int) *csvIndex {
+	return &csvIndex{
+		Version:  csvIndexVersion,
+		FirstRow: firstRow,
+		Tags:     make(map[string]postingList),
+		last:     make(map[string]indexEntry),
+	}
+}
+
+func csvIndexPath(path string) string {
+	return path + ".idx"
+}
+
+// add records the next data record, starting at offset.
+func (x *csvIndex) add(offset int64, tags []string) {
+	e := indexEntry{pos: x.records, offset: offset}
+	x.records++
+	for _, v := range tags {
+		prev, ok := x.last[v]
+		if ok && prev.pos == e.pos {
+			continue
+		}
+		x.Tags[v] = x.Tags[v].append(prev, e)
+		x.last[v] = e
+	}
+}
+
+// lookup returns the ascending entries of the records carrying every tag, ok
+// is false when a posting list is malformed.
+func (x *csvIndex) lookup(tags []string) (entries []indexEntry, ok bool) {
+	postings := make([][]indexEntry, 0, len(tags))
+	for _, v := range tags {
+		posting, ok := x.Tags[v].entries()
+		if !ok {
+			return nil, false
+		}
+		postings = append(postings, posting)
+	}
+	// Intersecting from the shortest list keeps every step small.
+	sort.Slice(postings, func(i, j int) bool {
+		return len(postings[i]) < len(postings[j])
+	})
+
+	if len(postings) == 0 {
+		return nil, true
+	}
+	entries = postings[0]
+	for _, posting := range postings[1:] {
+		entries = intersectPostings(entries, posting)
+	}
+	return entries, true
+}
+
+func intersectPostings(a, b []indexEntry) []indexEntry {
+	var result []indexEntry
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i].pos < b[j].pos:
+			i++
+		case a[i].pos > b[j].pos:
+			j++
+		default:
+			result = append(result, a[i])
+			i++
+			j++
+		}
+	}
+	return result
+}
+
+// checksumWriter counts and checksums the bytes written through it.
+type checksumWriter struct {
+	w   io.Writer
+	n   int64
+	crc hash.Hash32
+}
+
+func newChecksumWriter(w io.Writer) *checksumWriter {
+	return &checksumWriter{w: w, crc: crc32.New(crc32cTable)}
+}
+
+func (c *checksumWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.crc.Write(p[:n])
+	return n, err
+}
+
+// writeIndex replaces the index of path. The index is renamed into place
+// before the CSV itself, if the CSV is not replaced afterwards the checksum
+// no longer matches and the index is ignored.
+func (b *csvBackend) writeIndex(path string, index *csvIndex) (err error) {
+	file, err := b.fileReader.CreateTemp(filepath.Dir(path), "."+filepath.Base(csvIndexPath(path))+".tmp-*", path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			file.Close()
+			b.fileReader.Remove(file.Name())
+		}
+	}()
+
+	w := bufio.NewWriter(file)
+	if err = json.NewEncoder(w).Encode(index); err != nil {
+		return err
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	return b.fileReader.Rename(file.Name(), csvIndexPath(path))
+}
+
+// loadIndex returns the index of path, or nil when there is none or when it
+// does not match the current content of path.
+func (b *csvBackend) loadIndex(path string) *csvIndex {
+	file, err := b.fileReader.Open(csvIndexPath(path))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var index csvIndex
+	if err := json.NewDecoder(bufio.NewReader(file)).Decode(&index); err != nil {
+		return nil
+	}
+	if index.Version != csvIndexVersion {
+		return nil
+	}
+
+	data, err := b.fileReader.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer data.Close()
+
+	// The size alone tells most rewrites apart without reading the file.
+	info, err := data.Stat()
+	if err != nil || info.Size() != index.Size {
+		return nil
+	}
+	sum := newChecksumWriter(io.Discard)
+	if _, err := io.Copy(sum, data); err != nil {
+		return nil
+	}
+	if sum.n != index.Size || sum.crc.Sum32() != index.CRC32C {
+		return nil
+	}
+	return &index
+}
@@ -0,0 +1,175 @@
+package src
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_csvIndex_lookup(t *testing.T) {
+	index := newCSVIndex(3)
+	index.add(10, []string{"a", "b"})
+	index.add(20, []string{"b", "b"})
+	index.add(30, []string{"a", "c", "b"})
+	index.add(40, nil)
+
+	tests := []struct {
+		name string
+		tags []string
+		want []indexEntry
+	}{
+		{
+			name: "test1_single",
+			tags: []string{"b"},
+			want: []indexEntry{{0, 10}, {1, 20}, {2, 30}},
+		},
+		{
+			name: "test2_intersection",
+			tags: []string{"b", "a"},
+			want: []indexEntry{{0, 10}, {2, 30}},
+		},
+		{
+			name: "test3_no_match",
+			tags: []string{"a", "z"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := index.lookup(tt.tags)
+			if !ok || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("csvIndex.lookup() = %v, %v, want %v", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func Test_csvBackend_scanTags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.csv")
+	s := newStorage()
+	data := []UserData{
+		{ID: "1", Balance: "$1.00", Tags: []string{"a", "b"}},
+		{ID: "2", Balance: "$2.00", Tags: []string{"b"}},
+		{ID: "3", Balance: "$3.00", Tags: []string{"a", "multi\nline"}},
+	}
+	if err := s.storeAndReplace(context.Background(), data, path); err != nil {
+		t.Fatalf("storage.storeAndReplace() error = %v", err)
+	}
+
+	search := func(tags ...string) (ids []string, rows []int) {
+		b := &csvBackend{fileReader: &fileHandler{}, csvHandler: &csvHandler{}}
+		_, err := b.scanTags(context.Background(), path, tags, func(row int, user UserData, rowErr error) error {
+			ids = append(ids, user.ID)
+			rows = append(rows, row)
+			return rowErr
+		})
+		if err != nil {
+			t.Fatalf("csvBackend.scanTags() error = %v", err)
+		}
+		return ids, rows
+	}
+
+	ids, rows := search("a")
+	if want := []string{"1", "3"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("csvBackend.scanTags() = %v, want %v", ids, want)
+	}
+	if want := []int{3, 5}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("csvBackend.scanTags() rows = %v, want %v", rows, want)
+	}
+
+	// Drop row 1 from the posting list of "a": a search answered from the
+	// index no longer finds it.
+	content, err := ioutil.ReadFile(csvIndexPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var index csvIndex
+	if err := json.Unmarshal(content, &index); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := index.Tags["a"].entries()
+	index.Tags["a"] = postingList{}.append(indexEntry{}, entries[1])
+	content, _ = json.Marshal(index)
+	if err := ioutil.WriteFile(csvIndexPath(path), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if ids, _ := search("a"); !reflect.DeepEqual(ids, []string{"3"}) {
+		t.Errorf("csvBackend.scanTags() = %v, want the index to be used", ids)
+	}
+
+	// Any change to the CSV makes the index stale and falls back to a scan
+	// of every row, left for the filter to narrow.
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString("4,false,$4.00,\"[\"\"a\"\"]\"\n")
+	file.Close()
+	if ids, _ := search("a"); !reflect.DeepEqual(ids, []string{"1", "2", "3", "4"}) {
+		t.Errorf("csvBackend.scanTags() = %v, want a full scan", ids)
+	}
+}
+
+func Test_csvBackend_loadIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.csv")
+	s := newStorage()
+	var data []UserData
+	for i := 0; i < 1000; i++ {
+		data = append(data, UserData{ID: fmt.Sprintf("%06d", i), Balance: "$1,000.00", Tags: []string{"a", "b", fmt.Sprintf("t%d", i%10)}})
+	}
+	if err := s.storeAndReplace(context.Background(), data, path); err != nil {
+		t.Fatalf("storage.storeAndReplace() error = %v", err)
+	}
+	b := &csvBackend{fileReader: &fileHandler{}, csvHandler: &csvHandler{}}
+
+	csvInfo, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idxInfo, err := os.Stat(csvIndexPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idxInfo.Size() > csvInfo.Size()/4 {
+		t.Errorf("index size = %d, want at most a quarter of the CSV size %d", idxInfo.Size(), csvInfo.Size())
+	}
+	if b.loadIndex(path) == nil {
+		t.Fatal("csvBackend.loadIndex() = nil, want the index")
+	}
+
+	// A touched file keeps its index once the checksum matches.
+	touched := csvInfo.ModTime().Add(time.Hour)
+	if err := os.Chtimes(path, touched, touched); err != nil {
+		t.Fatal(err)
+	}
+	if b.loadIndex(path) == nil {
+		t.Error("csvBackend.loadIndex() = nil, want the index of a touched file")
+	}
+
+	// An edit keeping the size is caught by the checksum.
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := bytes.Replace(content, []byte("000000,false"), []byte("000000,true_"), 1)
+	if err := ioutil.WriteFile(path, edited, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if b.loadIndex(path) != nil {
+		t.Error("csvBackend.loadIndex() = index, want nil after an edit")
+	}
+
+	// So is an edit keeping the size and the modification time.
+	if err := os.Chtimes(path, csvInfo.ModTime(), csvInfo.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if b.loadIndex(path) != nil {
+		t.Error("csvBackend.loadIndex() = index, want nil after an edit keeping size and time")
+	}
+}
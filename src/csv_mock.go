@@ -34,6 +34,20 @@ func (m *MockcsvWriterIface) EXPECT() *MockcsvWriterIfaceMockRecorder {
 	return m.recorder
 }
 
+// Error mocks base method.
+func (m *MockcsvWriterIface) Error() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Error")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Error indicates an expected call of Error.
+func (mr *MockcsvWriterIfaceMockRecorder) Error() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Error", reflect.TypeOf((*MockcsvWriterIface)(nil).Error))
+}
+
 // Flush mocks base method.
 func (m *MockcsvWriterIface) Flush() {
 	m.ctrl.T.Helper()
@@ -0,0 +1,95 @@
+package src
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// DaemonOptions configures RunDaemon.
+type DaemonOptions struct {
+	// Path is the data file refreshed.
+	Path string
+	// Sources are fetched on every refresh, the sources of the Client when
+	// empty.
+	Sources []Source
+	// Schedule decides when the refreshes run.
+	Schedule Schedule
+	// Refresh replaces the default refresh, which streams Sources into Path
+	// like Client.FetchAndStore.
+	Refresh func(ctx context.Context) error
+	// Immediate refreshes once on start instead of waiting for the first
+	// scheduled run.
+	Immediate bool
+	// Logger receives a line per refresh, log.Default() when nil.
+	Logger *log.Logger
+}
+
+// RunDaemon runs the daemon of the Client behind the package level
+// functions, see Default and Client.RunDaemon.
+func RunDaemon(ctx context.Context, opt DaemonOptions) error {
+	return Default().RunDaemon(ctx, opt)
+}
+
+// RunDaemon refreshes the data file on opt.Schedule until ctx is done, which
+// also cancels the refresh in flight. Refreshes never overlap: a refresh
+// running past its successor's time delays it. A failed refresh is logged and
+// leaves the previous data in place, since every write replaces the file as
+// a whole. RunDaemon returns nil once ctx is done, and an error only when the
+// options are invalid or the schedule stops running.
+func (c *Client) RunDaemon(ctx context.Context, opt DaemonOptions) error {
+	if opt.Schedule == nil {
+		return fmt.Errorf("%w: none given", ErrInvalidSchedule)
+	}
+	if opt.Schedule.Next(time.Now()).IsZero() {
+		return fmt.Errorf("%w: it never runs", ErrInvalidSchedule)
+	}
+	logger := opt.Logger
+	if logger == nil {
+		logger = log.Default()
+	}
+	refresh := opt.Refresh
+	if refresh == nil {
+		fetcher := c.WithSources(opt.Sources...)
+		refresh = func(ctx context.Context) error {
+			return fetcher.FetchAndStore(ctx, opt.Path)
+		}
+	}
+
+	run := func() {
+		start := time.Now()
+		err := refresh(ctx)
+		elapsed := time.Since(start).Round(time.Millisecond)
+		switch {
+		case err == nil:
+			logger.Printf("Refreshed %s in %s", opt.Path, elapsed)
+		case ctx.Err() != nil:
+			logger.Printf("Refresh of %s cancelled after %s, keeping the previous data", opt.Path, elapsed)
+		default:
+			logger.Printf("Refresh of %s failed after %s, keeping the previous data: %v", opt.Path, elapsed, err)
+		}
+	}
+
+	if opt.Immediate && ctx.Err() == nil {
+		run()
+	}
+	for ctx.Err() == nil {
+		next := opt.Schedule.Next(time.Now())
+		if next.IsZero() {
+			return errors.New("the schedule has no further run")
+		}
+		logger.Printf("Next refresh of %s at %s", opt.Path, next.Format(time.RFC3339))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+		run()
+	}
+	return nil
+}
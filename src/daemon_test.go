@@ -0,0 +1,125 @@
+package src
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestRunDaemon(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var logs bytes.Buffer
+	calls := 0
+	err := RunDaemon(ctx, DaemonOptions{
+		Path:      "data.csv",
+		Schedule:  Every(time.Millisecond),
+		Immediate: true,
+		Logger:    log.New(&logs, "", 0),
+		Refresh: func(ctx context.Context) error {
+			calls++
+			switch calls {
+			case 2:
+				return errors.New("all links are down")
+			case 3:
+				// The refresh in flight sees the cancellation.
+				cancel()
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("RunDaemon() error = %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("RunDaemon() refreshed %d times, want 3", calls)
+	}
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	want := []string{
+		"Refreshed data.csv in ",
+		"Next refresh of data.csv at ",
+		"Refresh of data.csv failed after ",
+		"Next refresh of data.csv at ",
+		"Refresh of data.csv cancelled after ",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("RunDaemon() logged %q", lines)
+	}
+	for i, prefix := range want {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("RunDaemon() log line %d = %q, want prefix %q", i, lines[i], prefix)
+		}
+	}
+	if !strings.HasSuffix(lines[2], "keeping the previous data: all links are down") {
+		t.Errorf("RunDaemon() log line 2 = %q", lines[2])
+	}
+}
+
+func TestRunDaemon_default_refresh(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client, mock := newMockClient(mockCtrl)
+	sources := []Source{{URL: "http://127.0.0.1/users.json"}}
+	mock.EXPECT().FetchAndStoreUserData(gomock.Any(), sources, "data.csv").DoAndReturn(
+		func(ctx context.Context, sources []Source, path string) error {
+			cancel()
+			return nil
+		}).Times(1)
+
+	err := client.RunDaemon(ctx, DaemonOptions{
+		Path:     "data.csv",
+		Sources:  sources,
+		Schedule: Every(time.Millisecond),
+		Logger:   log.New(&bytes.Buffer{}, "", 0),
+	})
+	if err != nil {
+		t.Errorf("RunDaemon() error = %v", err)
+	}
+}
+
+func TestRunDaemon_errors(t *testing.T) {
+	noop := func(ctx context.Context) error { return nil }
+	never, err := ParseSchedule("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		opt     DaemonOptions
+		wantErr string
+	}{
+		{
+			name:    "test1_no_schedule",
+			opt:     DaemonOptions{Refresh: noop},
+			wantErr: "invalid schedule: none given",
+		},
+		{
+			name:    "test2_schedule_never_runs",
+			opt:     DaemonOptions{Schedule: never, Refresh: noop, Immediate: true},
+			wantErr: "invalid schedule: it never runs",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opt.Logger = log.New(&bytes.Buffer{}, "", 0)
+			err := RunDaemon(context.Background(), tt.opt)
+			if !errors.Is(err, ErrInvalidSchedule) || err.Error() != tt.wantErr {
+				t.Errorf("RunDaemon() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package src
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+type (
+	// Diff lists how the users of one data file differ from another.
+	Diff struct {
+		// Added holds the users only found in the newer file, in its order.
+		Added []UserData `json:"added"`
+		// Removed holds the users only found in the older file, in its order.
+		Removed []UserData `json:"removed"`
+		// Modified holds the users found in both files with different fields,
+		// in the order of the newer file.
+		Modified []UserChange `json:"modified"`
+	}
+
+	// UserChange describes how a user differs between two data files.
+	UserChange struct {
+		ID     string   `json:"id"`
+		Before UserData `json:"before"`
+		After  UserData `json:"after"`
+		// TagsAdded and TagsRemoved are sorted, the order of tags is ignored.
+		TagsAdded   []string `json:"tagsAdded,omitempty"`
+		TagsRemoved []string `json:"tagsRemoved,omitempty"`
+	}
+)
+
+// BalanceChanged reports whether the balance differs.
+func (c UserChange) BalanceChanged() bool {
+	return c.Before.Balance != c.After.Balance
+}
+
+// ActiveChanged reports whether the active status flipped.
+func (c UserChange) ActiveChanged() bool {
+	return c.Before.ActiveStatus != c.After.ActiveStatus
+}
+
+// Empty reports whether there is no difference at all.
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// diffUserData compares the users of from and to by ID. When a file repeats
+// an ID the last record wins, like in upsertUserData.
+func diffUserData(from, to []UserData) Diff {
+	diff := Diff{
+		Added:    []UserData{},
+		Removed:  []UserData{},
+		Modified: []UserChange{},
+	}
+	before := lastByID(from)
+	after := lastByID(to)
+
+	for _, id := range idOrder(to) {
+		newer := after[id]
+		older, ok := before[id]
+		if !ok {
+			diff.Added = append(diff.Added, newer)
+			continue
+		}
+		change := UserChange{ID: id, Before: older, After: newer}
+		change.TagsAdded, change.TagsRemoved = diffTags(older.Tags, newer.Tags)
+		if change.BalanceChanged() || change.ActiveChanged() || len(change.TagsAdded) > 0 || len(change.TagsRemoved) > 0 {
+			diff.Modified = append(diff.Modified, change)
+		}
+	}
+	for _, id := range idOrder(from) {
+		if _, ok := after[id]; !ok {
+			diff.Removed = append(diff.Removed, before[id])
+		}
+	}
+	return diff
+}
+
+func lastByID(data []UserData) map[string]UserData {
+	users := make(map[string]UserData, len(data))
+	for _, v := range data {
+		users[v.ID] = v
+	}
+	return users
+}
+
+// idOrder returns the distinct IDs of data in the order they first appear.
+func idOrder(data []UserData) []string {
+	seen := make(map[string]struct{}, len(data))
+	ids := make([]string, 0, len(data))
+	for _, v := range data {
+		if _, ok := seen[v.ID]; ok {
+			continue
+		}
+		seen[v.ID] = struct{}{}
+		ids = append(ids, v.ID)
+	}
+	return ids
+}
+
+func diffTags(before, after []string) (added, removed []string) {
+	set := func(tags []string) map[string]struct{} {
+		m := make(map[string]struct{}, len(tags))
+		for _, v := range tags {
+			m[v] = struct{}{}
+		}
+		return m
+	}
+	older, newer := set(before), set(after)
+	for v := range newer {
+		if _, ok := older[v]; !ok {
+			added = append(added, v)
+		}
+	}
+	for v := range older {
+		if _, ok := newer[v]; !ok {
+			removed = append(removed, v)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// WriteDiff prints diff to w as FormatText or FormatJSON. Text output has one
+// line per user: "+" for added, "-" for removed and "~" for modified users
+// followed by the changed fields.
+func WriteDiff(w io.Writer, diff Diff, format string) error {
+	switch strings.ToLower(format) {
+	case FormatText, "":
+		return writeDiffText(w, diff)
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diff)
+	}
+	return fmt.Errorf("unknown diff format %q, expected %s or %s", format, FormatText, FormatJSON)
+}
+
+func writeDiffText(w io.Writer, diff Diff) error {
+	bw := bufio.NewWriter(w)
+	for _, v := range diff.Added {
+		fmt.Fprintf(bw, "+ %s active=%t balance=%s tags=%s\n", v.ID, v.ActiveStatus, v.Balance, strings.Join(v.Tags, ","))
+	}
+	for _, v := range diff.Removed {
+		fmt.Fprintf(bw, "- %s active=%t balance=%s tags=%s\n", v.ID, v.ActiveStatus, v.Balance, strings.Join(v.Tags, ","))
+	}
+	for _, v := range diff.Modified {
+		changes := []string{}
+		if v.ActiveChanged() {
+			changes = append(changes, fmt.Sprintf("active %t -> %t", v.Before.ActiveStatus, v.After.ActiveStatus))
+		}
+		if v.BalanceChanged() {
+			changes = append(changes, fmt.Sprintf("balance %s -> %s", v.Before.Balance, v.After.Balance))
+		}
+		for _, tag := range v.TagsAdded {
+			changes = append(changes, "tag +"+tag)
+		}
+		for _, tag := range v.TagsRemoved {
+			changes = append(changes, "tag -"+tag)
+		}
+		fmt.Fprintf(bw, "~ %s %s\n", v.ID, strings.Join(changes, ", "))
+	}
+	fmt.Fprintf(bw, "%d added, %d removed, %d modified\n", len(diff.Added), len(diff.Removed), len(diff.Modified))
+	return bw.Flush()
+}
@@ -0,0 +1,160 @@
+package src
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func Test_diffUserData(t *testing.T) {
+	tests := []struct {
+		name string
+		from []UserData
+		to   []UserData
+		want Diff
+	}{
+		{
+			name: "test1_added_removed_modified",
+			from: []UserData{
+				{ID: "1", ActiveStatus: true, Balance: "$1.00", Tags: []string{"a", "b"}},
+				{ID: "2", Balance: "$2.00"},
+				{ID: "3", Balance: "$3.00", Tags: []string{"a"}},
+			},
+			to: []UserData{
+				{ID: "4", Balance: "$4.00"},
+				{ID: "3", Balance: "$3.00", Tags: []string{"a"}},
+				{ID: "1", Balance: "$1.50", Tags: []string{"c", "b"}},
+			},
+			want: Diff{
+				Added:   []UserData{{ID: "4", Balance: "$4.00"}},
+				Removed: []UserData{{ID: "2", Balance: "$2.00"}},
+				Modified: []UserChange{
+					{
+						ID:          "1",
+						Before:      UserData{ID: "1", ActiveStatus: true, Balance: "$1.00", Tags: []string{"a", "b"}},
+						After:       UserData{ID: "1", Balance: "$1.50", Tags: []string{"c", "b"}},
+						TagsAdded:   []string{"c"},
+						TagsRemoved: []string{"a"},
+					},
+				},
+			},
+		},
+		{
+			name: "test2_tag_order_and_duplicates",
+			from: []UserData{
+				{ID: "1", Tags: []string{"a", "b"}},
+				{ID: "1", Tags: []string{"b", "a"}},
+			},
+			to: []UserData{
+				{ID: "1", Balance: "$1.00"},
+				{ID: "1", Tags: []string{"b", "a", "a"}},
+			},
+			want: Diff{Added: []UserData{}, Removed: []UserData{}, Modified: []UserChange{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffUserData(tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffUserData() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteDiff(t *testing.T) {
+	diff := Diff{
+		Added:   []UserData{{ID: "4", ActiveStatus: true, Balance: "$4.00", Tags: []string{"a", "b"}}},
+		Removed: []UserData{{ID: "2", Balance: "$2.00"}},
+		Modified: []UserChange{
+			{
+				ID:          "1",
+				Before:      UserData{ID: "1", ActiveStatus: true, Balance: "$1.00"},
+				After:       UserData{ID: "1", Balance: "$1.50"},
+				TagsAdded:   []string{"c"},
+				TagsRemoved: []string{"a"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "test1_text",
+			format: FormatText,
+			want: "+ 4 active=true balance=$4.00 tags=a,b\n" +
+				"- 2 active=false balance=$2.00 tags=\n" +
+				"~ 1 active true -> false, balance $1.00 -> $1.50, tag +c, tag -a\n" +
+				"1 added, 1 removed, 1 modified\n",
+		},
+		{
+			name:   "test2_json",
+			format: FormatJSON,
+			want: `{
+  "added": [
+    {
+      "_id": "4",
+      "isActive": true,
+      "balance": "$4.00",
+      "tags": [
+        "a",
+        "b"
+      ]
+    }
+  ],
+  "removed": [
+    {
+      "_id": "2",
+      "isActive": false,
+      "balance": "$2.00",
+      "tags": null
+    }
+  ],
+  "modified": [
+    {
+      "id": "1",
+      "before": {
+        "_id": "1",
+        "isActive": true,
+        "balance": "$1.00",
+        "tags": null
+      },
+      "after": {
+        "_id": "1",
+        "isActive": false,
+        "balance": "$1.50",
+        "tags": null
+      },
+      "tagsAdded": [
+        "c"
+      ],
+      "tagsRemoved": [
+        "a"
+      ]
+    }
+  ]
+}
+`,
+		},
+		{
+			name:    "test3_unknown_format",
+			format:  FormatYAML,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := WriteDiff(&buf, diff, tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WriteDiff() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("WriteDiff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package src
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"strings"
+)
+
+// Errors returned by the package, wrapped or not, to be checked with
+// errors.Is.
+var (
+	// ErrMissingFile is wrapped when the data file does not exist. Other
+	// failures to open it are returned as the *fs.PathError, so that
+	// errors.Is(err, fs.ErrPermission) holds for a file that cannot be read.
+	ErrMissingFile = errors.New("missing file")
+	// ErrStopSearch is returned by the callback of a streaming search to stop
+	// it early, the search then returns nil.
+	ErrStopSearch = errors.New("stop search")
+
+	// ErrNoSources is returned by a fetch given no source to request.
+	ErrNoSources = errors.New("all links are invalid")
+	// ErrSourcesDown is matched by the *SourcesError returned when no source
+	// answered with users.
+	ErrSourcesDown = errors.New("all links are down or gives unexpected response")
+	// ErrInvalidSource is wrapped by the *FetchError of a source that cannot
+	// be requested, such as one missing its URL or using an unknown method.
+	ErrInvalidSource = errors.New("invalid source")
+	// ErrUnexpectedStatus is wrapped by the *FetchError of a source answering
+	// with another code than 200.
+	ErrUnexpectedStatus = errors.New("unexpected response code")
+	// ErrBodyTooLarge is wrapped by the *FetchError of a response larger than
+	// ClientOptions.MaxBodySize.
+	ErrBodyTooLarge = errors.New("response body too large")
+
+	// ErrBadRow is wrapped by the *ParseError of a CSV row missing columns.
+	ErrBadRow = errors.New("bad csv row format")
+	// ErrEmptyID and ErrDuplicateID are wrapped by the RowProblem of a row
+	// search would skip or shadow, and by the failed write of a user the kv
+	// storage cannot key by ID.
+	ErrEmptyID     = errors.New("empty id")
+	ErrDuplicateID = errors.New("duplicated id")
+
+	// ErrUnsupportedSchema is wrapped when a data file was written in a
+	// schema version newer than SchemaVersion, by a newer ccli.
+	ErrUnsupportedSchema = errors.New("unsupported schema version")
+	// ErrBadSchema is wrapped when the schema version, header or layout of
+	// a data file is missing or invalid.
+	ErrBadSchema = errors.New("invalid schema")
+	// ErrMalformedFile is wrapped when a data file cannot be decoded past
+	// the failing row, such as truncated or broken JSON.
+	ErrMalformedFile = errors.New("malformed data file")
+
+	// ErrInvalidSchedule is wrapped by RunDaemon when DaemonOptions.Schedule
+	// is missing or never runs, before any refresh.
+	ErrInvalidSchedule = errors.New("invalid schedule")
+)
+
+// FetchError is the failure of a single source. StatusCode is the code the
+// source answered with, 0 when no response was received.
+type FetchError struct {
+	URL        string
+	StatusCode int
+	Err        error
+}
+
+func (e *FetchError) Error() string {
+	var urlErr *url.Error
+	switch {
+	case errors.As(e.Err, &urlErr):
+		// already names the method and the URL
+		return e.Err.Error()
+	case errors.Is(e.Err, ErrUnexpectedStatus):
+		return fmt.Sprintf("%s: %v %d", e.URL, e.Err, e.StatusCode)
+	}
+	return fmt.Sprintf("%s: %v", e.URL, e.Err)
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+// SourcesError is returned when every source was requested and none answered
+// with users. Errs holds the *FetchError of each source in the order they
+// failed. It matches ErrSourcesDown, and errors.Is and errors.As look into
+// Errs as well.
+type SourcesError struct {
+	Errs []error
+}
+
+func (e *SourcesError) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+	if len(msgs) == 0 {
+		return ErrSourcesDown.Error()
+	}
+	return fmt.Sprintf("%v: %s", ErrSourcesDown, strings.Join(msgs, "; "))
+}
+
+func (e *SourcesError) Is(target error) bool {
+	if target == ErrSourcesDown {
+		return true
+	}
+	for _, err := range e.Errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *SourcesError) As(target interface{}) bool {
+	for _, err := range e.Errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseError is a stored row that cannot be read as a user. Column names the
+// field at fault in the terms of the storage format, such as "active" for
+// CSV or "isActive" for JSON, and is empty when the whole row is malformed.
+type ParseError struct {
+	Row    int
+	Column string
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// openError returns the error of opening or looking up a data file,
+// wrapping ErrMissingFile when it does not exist.
+func openError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %v", ErrMissingFile, err)
+	}
+	return err
+}
+
+// rowError returns the error decoding row as a *ParseError, nil when err is
+// nil. The column of a ParseError or a JSON type error is kept.
+func rowError(row int, err error) error {
+	if err == nil {
+		return nil
+	}
+	switch e := err.(type) {
+	case *ParseError:
+		return &ParseError{Row: row, Column: e.Column, Err: e.Err}
+	case *json.UnmarshalTypeError:
+		return &ParseError{Row: row, Column: e.Field, Err: err}
+	}
+	return &ParseError{Row: row, Err: err}
+}
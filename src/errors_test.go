@@ -0,0 +1,242 @@
+package src
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jarcoal/httpmock"
+)
+
+func TestFetchError(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://localhost:8400", httpmock.NewStringResponder(http.StatusNotFound, ""))
+	httpmock.RegisterResponder("GET", "http://localhost:8401", httpmock.NewStringResponder(http.StatusServiceUnavailable, ""))
+	httpmock.RegisterResponder("GET", "http://localhost:8402", httpmock.NewStringResponder(http.StatusOK, `[{"_id": "1"}, {"_id": "2"}]`))
+
+	tests := []struct {
+		name       string
+		sources    []Source
+		maxBody    int64
+		wantIs     []error
+		wantURL    string
+		wantStatus int
+	}{
+		{
+			name:    "test1_no_sources",
+			sources: nil,
+			wantIs:  []error{ErrNoSources},
+		},
+		{
+			name: "test2_sources_down",
+			sources: []Source{
+				{URL: "http://localhost:8400", Method: http.MethodGet, Priority: 1},
+				{URL: "http://localhost:8401", Method: http.MethodGet, Priority: 2},
+			},
+			wantIs:     []error{ErrSourcesDown, ErrUnexpectedStatus},
+			wantURL:    "http://localhost:8400",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:    "test3_invalid_method",
+			sources: []Source{{URL: "http://localhost:8402", Method: "PATCH"}},
+			wantIs:  []error{ErrInvalidSource},
+			wantURL: "http://localhost:8402",
+		},
+		{
+			name:       "test4_body_too_large",
+			sources:    []Source{{URL: "http://localhost:8402", Method: http.MethodGet}},
+			maxBody:    10,
+			wantIs:     []error{ErrBodyTooLarge},
+			wantURL:    "http://localhost:8402",
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &apiFetcher{
+				httpClient: &http.Client{},
+				maxBody:    tt.maxBody,
+			}
+			_, err := f.getSampleAPIResourceRedirect(context.Background(), tt.sources)
+			for _, v := range tt.wantIs {
+				if !errors.Is(err, v) {
+					t.Errorf("apiFetcher.getSampleAPIResourceRedirect() error = %v, want %v", err, v)
+				}
+			}
+
+			var fetchErr *FetchError
+			if !errors.As(err, &fetchErr) {
+				if tt.wantURL != "" {
+					t.Errorf("apiFetcher.getSampleAPIResourceRedirect() error = %v, want a *FetchError", err)
+				}
+				return
+			}
+			if fetchErr.URL != tt.wantURL || fetchErr.StatusCode != tt.wantStatus {
+				t.Errorf("FetchError = %q %d, want %q %d", fetchErr.URL, fetchErr.StatusCode, tt.wantURL, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		name       string
+		file       string
+		content    string
+		wantIs     error
+		wantRow    int
+		wantColumn string
+	}{
+		{
+			name:    "test1_csv_missing_columns",
+			file:    "data.csv",
+			content: "1,true,1000,[]\n2,true\n",
+			wantIs:  ErrBadRow,
+			wantRow: 2,
+		},
+		{
+			name:       "test2_csv_active",
+			file:       "data.csv",
+			content:    "1,true,1000,[]\n2,yes,1000,[]\n",
+			wantRow:    2,
+			wantColumn: "active",
+		},
+		{
+			name:       "test3_ndjson_type",
+			file:       "data.ndjson",
+			content:    "{\"_id\": \"1\"}\n{\"_id\": \"2\", \"isActive\": \"yes\"}\n",
+			wantRow:    2,
+			wantColumn: "isActive",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := ioutil.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := newStorage().(*storage).readAll(context.Background(), path)
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("storage.readAll() error = %v, want a *ParseError", err)
+			}
+			if parseErr.Row != tt.wantRow || parseErr.Column != tt.wantColumn {
+				t.Errorf("ParseError = row %d column %q, want row %d column %q", parseErr.Row, parseErr.Column, tt.wantRow, tt.wantColumn)
+			}
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Errorf("storage.readAll() error = %v, want %v", err, tt.wantIs)
+			}
+		})
+	}
+}
+
+func TestSchemaErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantIs  error
+	}{
+		{
+			name:    "test1_csv_newer_version",
+			file:    "data.csv",
+			content: "#schema,99\nid,active,balance,tags\n",
+			wantIs:  ErrUnsupportedSchema,
+		},
+		{
+			name:    "test2_csv_bad_version",
+			file:    "data.csv",
+			content: "#schema,x\nid,active,balance,tags\n",
+			wantIs:  ErrBadSchema,
+		},
+		{
+			name:    "test3_csv_missing_header",
+			file:    "data.csv",
+			content: "#schema,2\n",
+			wantIs:  ErrBadSchema,
+		},
+		{
+			name:    "test4_csv_missing_column",
+			file:    "data.csv",
+			content: "#schema,2\nid,active,balance\n",
+			wantIs:  ErrBadSchema,
+		},
+		{
+			name:    "test5_json_not_array",
+			file:    "data.json",
+			content: `{"_id": "1"}`,
+			wantIs:  ErrMalformedFile,
+		},
+		{
+			name:    "test6_json_truncated",
+			file:    "data.json",
+			content: `[{"_id": "1"}, {"_id": `,
+			wantIs:  ErrMalformedFile,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := ioutil.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := newStorage().search(context.Background(), nil, path)
+			if !errors.Is(err, tt.wantIs) {
+				t.Errorf("storage.search() error = %v, want %v", err, tt.wantIs)
+			}
+		})
+	}
+}
+
+func TestOpenError(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	tests := []struct {
+		name        string
+		openErr     error
+		wantIs      error
+		wantMissing bool
+	}{
+		{
+			name:        "test1_missing",
+			openErr:     &fs.PathError{Op: "open", Path: "data.csv", Err: fs.ErrNotExist},
+			wantIs:      fs.ErrNotExist,
+			wantMissing: true,
+		},
+		{
+			name:    "test2_permission",
+			openErr: &fs.PathError{Op: "open", Path: "data.csv", Err: fs.ErrPermission},
+			wantIs:  fs.ErrPermission,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileReader := NewMockfReaderIface(mockCtrl)
+			fileReader.EXPECT().Open("data.csv").Return(nil, tt.openErr).Times(1)
+			s := &storage{fileReader: fileReader, csvHandler: &csvHandler{}}
+
+			_, err := s.search(context.Background(), nil, "data.csv")
+			if got := errors.Is(err, ErrMissingFile); got != tt.wantMissing {
+				t.Errorf("storage.search() error = %v, is ErrMissingFile %v, want %v", err, got, tt.wantMissing)
+			}
+			if !strings.Contains(err.Error(), tt.wantIs.Error()) {
+				t.Errorf("storage.search() error = %v, want the cause %v", err, tt.wantIs)
+			}
+			if !tt.wantMissing && !errors.Is(err, tt.wantIs) {
+				t.Errorf("storage.search() error = %v, want %v", err, tt.wantIs)
+			}
+		})
+	}
+}
@@ -2,27 +2,31 @@ package src
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strings"
-)
-
-var (
-	errUnexpectedCode = errors.New("unexpected response code")
+	"sync"
 )
 
 //go:generate mockgen -destination=fetch_mock.go -package=src -source=fetch.go
 type (
 	apiFetcherIface interface {
-		getSampleAPIResourceRedirect(ctx context.Context, link []string) (data []UserData, err error)
+		getSampleAPIResourceRedirect(ctx context.Context, sources []Source) (data []UserData, err error)
+		getSampleAPIResourceFanOut(ctx context.Context, sources []Source) (data []UserData, err error)
+		getSampleAPIResourceMerged(ctx context.Context, sources []Source, rule MergeRule) (data []UserData, err error)
+		streamSampleAPIResourceRedirect(ctx context.Context, sources []Source, fn func(users userSeq) error) (err error)
 	}
 
 	apiFetcher struct {
 		// httpClient *http.Client
 		httpClient httpIface
+		retry      RetryPolicy
+		// maxBody is the largest response body read, 0 or less reads any.
+		maxBody int64
 	}
 
 	httpIface interface {
@@ -30,95 +34,315 @@ type (
 	}
 )
 
-func newFetcher(client *http.Client) (apiFetcherIface, error) {
+func newFetcher(client *http.Client, retry RetryPolicy, maxBody int64) (apiFetcherIface, error) {
 	if client == nil {
-		return nil, errors.New("missing required params")
+		return nil, errors.New("missing http client")
 	}
 	return &apiFetcher{
 		httpClient: client,
+		retry:      retry,
+		maxBody:    maxBody,
 	}, nil
 }
 
-func (f *apiFetcher) getSampleAPIResourceRedirect(ctx context.Context, link []string) (data []UserData, err error) {
+func (f *apiFetcher) getSampleAPIResourceRedirect(ctx context.Context, sources []Source) (data []UserData, err error) {
+	err = f.streamSampleAPIResourceRedirect(ctx, sources, func(users userSeq) error {
+		return users(func(user UserData) error {
+			data = append(data, user)
+			return nil
+		})
+	})
+	return data, err
+}
+
+// streamSampleAPIResourceRedirect requests the sources in priority order like
+// getSampleAPIResourceRedirect, but hands the users of the first 200 response
+// to fn as they are decoded instead of collecting them.
+func (f *apiFetcher) streamSampleAPIResourceRedirect(ctx context.Context, sources []Source, fn func(users userSeq) error) (err error) {
 	var (
 		validLinks = 0
-		validResp  = 0
+		failures   []error
 	)
-	for _, v := range link {
-		v = strings.TrimSpace(v)
-		if v == "" {
-			continue
-		}
+	for _, v := range orderSources(sources) {
 		validLinks++
 
-		resp, err := f.fetchHTTP(ctx, http.MethodGet, v)
-		if err != nil && err != errUnexpectedCode {
-			return data, err
-		}
-		if resp.code != http.StatusOK {
+		body, err := f.openSource(ctx, v)
+		if err != nil {
+			if !errors.Is(err, ErrUnexpectedStatus) {
+				return err
+			}
+			failures = append(failures, err)
 			continue
 		}
 
-		validResp++
-		err = json.Unmarshal(resp.content, &data)
-		if err != nil {
-			return data, err
+		err = fn(func(yield func(user UserData) error) error {
+			return decodeSource(v, body, yield)
+		})
+		body.Close()
+		return err
+	}
+
+	if validLinks == 0 {
+		return ErrNoSources
+	}
+
+	return &SourcesError{Errs: failures}
+}
+
+// getSampleAPIResourceFanOut requests every source concurrently and returns the
+// first 200 response that decodes successfully. The remaining requests are
+// cancelled through the shared context as soon as a winner is found, and
+// waited for so that no request is left running once it returns.
+func (f *apiFetcher) getSampleAPIResourceFanOut(ctx context.Context, sources []Source) (data []UserData, err error) {
+	type fanOutResult struct {
+		data []UserData
+		err  error
+	}
+
+	validLinks := orderSources(sources)
+
+	if len(validLinks) == 0 {
+		return data, ErrNoSources
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	// buffered so losing goroutines never block once a winner is found
+	results := make(chan fanOutResult, len(validLinks))
+	for _, v := range validLinks {
+		wg.Add(1)
+		go func(v Source) {
+			defer wg.Done()
+
+			body, err := f.openSource(ctx, v)
+			if err != nil {
+				results <- fanOutResult{err: err}
+				return
+			}
+			defer body.Close()
+
+			res, err := decodeSourceSlice(v, body)
+			results <- fanOutResult{data: res, err: err}
+		}(v)
+	}
+
+	var (
+		firstErr error
+		failures []error
+	)
+	for range validLinks {
+		res := <-results
+		if res.err == nil {
+			return res.data, nil
 		}
-		if err == nil {
-			return data, err
+		if errors.Is(res.err, ErrUnexpectedStatus) {
+			failures = append(failures, res.err)
+		} else if firstErr == nil {
+			firstErr = res.err
 		}
 	}
 
-	if validLinks == 0 {
-		return data, errors.New("all links are invalid")
+	if firstErr != nil {
+		return data, firstErr
 	}
 
-	if validResp == 0 {
-		return data, errors.New("all links are down or gives unexpected response")
+	return data, &SourcesError{Errs: failures}
+}
+
+// getSampleAPIResourceMerged requests every source concurrently and combines all
+// successful responses into one slice de-duplicated by ID according to rule.
+// Sources answering with an unexpected code are skipped, while request and
+// decoding errors abort the whole merge.
+func (f *apiFetcher) getSampleAPIResourceMerged(ctx context.Context, sources []Source, rule MergeRule) (data []UserData, err error) {
+	validLinks := orderSources(sources)
+
+	if len(validLinks) == 0 {
+		return data, ErrNoSources
 	}
 
-	return
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		results  = make([][]UserData, len(validLinks))
+		validOK  = 0
+		firstErr error
+		failures []error
+	)
+	for i, v := range validLinks {
+		wg.Add(1)
+		go func(i int, v Source) {
+			defer wg.Done()
+
+			var res []UserData
+			body, err := f.openSource(ctx, v)
+			if err == nil {
+				res, err = decodeSourceSlice(v, body)
+				body.Close()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if errors.Is(err, ErrUnexpectedStatus) {
+				failures = append(failures, err)
+				return
+			}
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				return
+			}
+			validOK++
+			results[i] = res
+		}(i, v)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return data, firstErr
+	}
+
+	if validOK == 0 {
+		return data, &SourcesError{Errs: failures}
+	}
+
+	return mergeUserData(results, rule), nil
+}
+
+// openSource requests a single source applying its method, headers and
+// timeout. body is the body of a 200 response, only set along with a nil error
+// and must be closed, the timeout keeps running until then. Errors are
+// returned as a *FetchError.
+func (f *apiFetcher) openSource(ctx context.Context, source Source) (body io.ReadCloser, err error) {
+	cancel := context.CancelFunc(func() {})
+	if source.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, source.Timeout)
+	}
+	code, body, err := f.openHTTP(ctx, source.Method, source.URL, source.Headers)
+	if err == nil && code != http.StatusOK {
+		err = ErrUnexpectedStatus
+	}
+	if err != nil {
+		if body != nil {
+			body.Close()
+		}
+		cancel()
+		return nil, &FetchError{URL: source.URL, StatusCode: code, Err: err}
+	}
+	return cancelOnClose{ReadCloser: body, cancel: cancel}, nil
+}
+
+// decodeSource passes the users of a source response to yield like
+// decodeUsers. Failures to read the response are returned as a *FetchError,
+// errors of yield as they are.
+func decodeSource(source Source, body io.Reader, yield func(user UserData) error) error {
+	var yieldErr error
+	err := decodeUsers(body, func(user UserData) error {
+		yieldErr = yield(user)
+		return yieldErr
+	})
+	if err != nil && err != yieldErr {
+		return &FetchError{URL: source.URL, StatusCode: http.StatusOK, Err: err}
+	}
+	return err
+}
+
+// decodeSourceSlice is decodeUserSlice returning a *FetchError.
+func decodeSourceSlice(source Source, body io.Reader) (data []UserData, err error) {
+	data, err = decodeUserSlice(body)
+	if err != nil {
+		return nil, &FetchError{URL: source.URL, StatusCode: http.StatusOK, Err: err}
+	}
+	return data, nil
+}
+
+// cancelOnClose cancels the context of a response once its body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
 }
 
 func (f *apiFetcher) fetchHTTP(ctx context.Context, method, link string) (resp httpResponseGeneral, err error) {
+	return f.fetchHTTPWithHeader(ctx, method, link, nil)
+}
+
+func (f *apiFetcher) fetchHTTPWithHeader(ctx context.Context, method, link string, header map[string]string) (resp httpResponseGeneral, err error) {
+	var body io.ReadCloser
+	resp.code, body, err = f.openHTTP(ctx, method, link, header)
+	if body == nil {
+		return resp, err
+	}
+	defer body.Close()
+	resp.content, err = ioutil.ReadAll(body)
+
+	return
+}
+
+// openHTTP sends the request and returns the response code, along with the
+// body when the code is a success. The body is limited to maxBody bytes and
+// must be closed.
+func (f *apiFetcher) openHTTP(ctx context.Context, method, link string, header map[string]string) (code int, body io.ReadCloser, err error) {
 	link, method = strings.TrimSpace(link), strings.TrimSpace(method)
 	if link == "" || method == "" {
-		return resp, errors.New("missing required params")
+		return code, nil, fmt.Errorf("%w: missing url or method", ErrInvalidSource)
 	}
 
 	_, err = url.ParseRequestURI(link)
 	if err != nil {
-		return
+		return code, nil, fmt.Errorf("%w: %v", ErrInvalidSource, err)
 	}
 
 	switch method {
 	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete:
 	default:
-		return resp, errors.New("invalid method")
+		return code, nil, fmt.Errorf("%w: method %q", ErrInvalidSource, method)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, method, link, nil)
 	if err != nil {
 		return
 	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
 
-	httpResp, err := f.httpClient.Do(req)
+	httpResp, err := f.doWithRetry(req)
 	if err != nil {
 		return
 	}
 
-	resp.code = httpResp.StatusCode
+	code = httpResp.StatusCode
 
-	switch resp.code {
+	switch code {
 	case http.StatusOK, http.StatusCreated, http.StatusAccepted, http.StatusNoContent:
 	case http.StatusServiceUnavailable:
+		closeBody(httpResp)
 		return
 	default:
-		return resp, errUnexpectedCode
+		closeBody(httpResp)
+		return code, nil, ErrUnexpectedStatus
 	}
 
-	defer httpResp.Body.Close()
-	resp.content, err = ioutil.ReadAll(httpResp.Body)
+	return code, limitBody(httpResp.Body, f.maxBody), nil
+}
 
-	return
+func closeBody(resp *http.Response) {
+	if resp.Body != nil {
+		resp.Body.Close()
+	}
 }
@@ -35,19 +35,63 @@ func (m *MockapiFetcherIface) EXPECT() *MockapiFetcherIfaceMockRecorder {
 	return m.recorder
 }
 
+// getSampleAPIResourceFanOut mocks base method.
+func (m *MockapiFetcherIface) getSampleAPIResourceFanOut(ctx context.Context, sources []Source) ([]UserData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "getSampleAPIResourceFanOut", ctx, sources)
+	ret0, _ := ret[0].([]UserData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// getSampleAPIResourceFanOut indicates an expected call of getSampleAPIResourceFanOut.
+func (mr *MockapiFetcherIfaceMockRecorder) getSampleAPIResourceFanOut(ctx, sources interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "getSampleAPIResourceFanOut", reflect.TypeOf((*MockapiFetcherIface)(nil).getSampleAPIResourceFanOut), ctx, sources)
+}
+
+// getSampleAPIResourceMerged mocks base method.
+func (m *MockapiFetcherIface) getSampleAPIResourceMerged(ctx context.Context, sources []Source, rule MergeRule) ([]UserData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "getSampleAPIResourceMerged", ctx, sources, rule)
+	ret0, _ := ret[0].([]UserData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// getSampleAPIResourceMerged indicates an expected call of getSampleAPIResourceMerged.
+func (mr *MockapiFetcherIfaceMockRecorder) getSampleAPIResourceMerged(ctx, sources, rule interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "getSampleAPIResourceMerged", reflect.TypeOf((*MockapiFetcherIface)(nil).getSampleAPIResourceMerged), ctx, sources, rule)
+}
+
 // getSampleAPIResourceRedirect mocks base method.
-func (m *MockapiFetcherIface) getSampleAPIResourceRedirect(ctx context.Context, link []string) ([]UserData, error) {
+func (m *MockapiFetcherIface) getSampleAPIResourceRedirect(ctx context.Context, sources []Source) ([]UserData, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "getSampleAPIResourceRedirect", ctx, link)
+	ret := m.ctrl.Call(m, "getSampleAPIResourceRedirect", ctx, sources)
 	ret0, _ := ret[0].([]UserData)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // getSampleAPIResourceRedirect indicates an expected call of getSampleAPIResourceRedirect.
-func (mr *MockapiFetcherIfaceMockRecorder) getSampleAPIResourceRedirect(ctx, link interface{}) *gomock.Call {
+func (mr *MockapiFetcherIfaceMockRecorder) getSampleAPIResourceRedirect(ctx, sources interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "getSampleAPIResourceRedirect", reflect.TypeOf((*MockapiFetcherIface)(nil).getSampleAPIResourceRedirect), ctx, sources)
+}
+
+// streamSampleAPIResourceRedirect mocks base method.
+func (m *MockapiFetcherIface) streamSampleAPIResourceRedirect(ctx context.Context, sources []Source, fn func(userSeq) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "streamSampleAPIResourceRedirect", ctx, sources, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// streamSampleAPIResourceRedirect indicates an expected call of streamSampleAPIResourceRedirect.
+func (mr *MockapiFetcherIfaceMockRecorder) streamSampleAPIResourceRedirect(ctx, sources, fn interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "getSampleAPIResourceRedirect", reflect.TypeOf((*MockapiFetcherIface)(nil).getSampleAPIResourceRedirect), ctx, link)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "streamSampleAPIResourceRedirect", reflect.TypeOf((*MockapiFetcherIface)(nil).streamSampleAPIResourceRedirect), ctx, sources, fn)
 }
 
 // MockhttpIface is a mock of httpIface interface.
@@ -15,7 +15,9 @@ func Test_newFetcher(t *testing.T) {
 	mockClient := &http.Client{}
 
 	type args struct {
-		client *http.Client
+		client  *http.Client
+		retry   RetryPolicy
+		maxBody int64
 	}
 	tests := []struct {
 		name    string
@@ -31,17 +33,21 @@ func Test_newFetcher(t *testing.T) {
 		{
 			name: "test2_success",
 			args: args{
-				client: mockClient,
+				client:  mockClient,
+				retry:   DefaultRetryPolicy(),
+				maxBody: DefaultMaxBodySize,
 			},
 			wantErr: false,
 			want: &apiFetcher{
 				httpClient: mockClient,
+				retry:      DefaultRetryPolicy(),
+				maxBody:    DefaultMaxBodySize,
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := newFetcher(tt.args.client)
+			got, err := newFetcher(tt.args.client, tt.args.retry, tt.args.maxBody)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("newFetcher() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -188,8 +194,8 @@ func Test_apiFetcher_getSampleAPIResourceRedirect(t *testing.T) {
 		httpClient httpIface
 	}
 	type args struct {
-		ctx  context.Context
-		link []string
+		ctx     context.Context
+		sources []Source
 	}
 	tests := []struct {
 		name     string
@@ -203,8 +209,9 @@ func Test_apiFetcher_getSampleAPIResourceRedirect(t *testing.T) {
 			name: "test1_all_links_are_invalid",
 			args: args{
 				ctx: context.Background(),
-				link: []string{
-					"  ", "  ",
+				sources: []Source{
+					{URL: "  "},
+					{URL: "  "},
 				},
 			},
 			wantErr: true,
@@ -213,8 +220,9 @@ func Test_apiFetcher_getSampleAPIResourceRedirect(t *testing.T) {
 			name: "test2_all_links_are_down",
 			args: args{
 				ctx: context.Background(),
-				link: []string{
-					"http://localhost:8080", "http://localhost:8081",
+				sources: []Source{
+					{URL: "http://localhost:8080"},
+					{URL: "http://localhost:8081"},
 				},
 			},
 			wantErr: true,
@@ -232,8 +240,9 @@ func Test_apiFetcher_getSampleAPIResourceRedirect(t *testing.T) {
 			name: "test3_success_fetch_first_link",
 			args: args{
 				ctx: context.Background(),
-				link: []string{
-					"http://localhost:8080", "http://localhost:8081",
+				sources: []Source{
+					{URL: "http://localhost:8080"},
+					{URL: "http://localhost:8081"},
 				},
 			},
 			wantErr: false,
@@ -265,8 +274,9 @@ func Test_apiFetcher_getSampleAPIResourceRedirect(t *testing.T) {
 			name: "test4_success_fetch_second_link",
 			args: args{
 				ctx: context.Background(),
-				link: []string{
-					"http://localhost:8080", "http://localhost:8081",
+				sources: []Source{
+					{URL: "http://localhost:8080"},
+					{URL: "http://localhost:8081"},
 				},
 			},
 			wantErr: false,
@@ -307,7 +317,7 @@ func Test_apiFetcher_getSampleAPIResourceRedirect(t *testing.T) {
 			f := &apiFetcher{
 				httpClient: tt.fields.httpClient,
 			}
-			gotData, err := f.getSampleAPIResourceRedirect(tt.args.ctx, tt.args.link)
+			gotData, err := f.getSampleAPIResourceRedirect(tt.args.ctx, tt.args.sources)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("apiFetcher.getSampleAPIResourceRedirect() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -318,3 +328,372 @@ func Test_apiFetcher_getSampleAPIResourceRedirect(t *testing.T) {
 		})
 	}
 }
+
+func Test_apiFetcher_getSampleAPIResourceFanOut(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	type fields struct {
+		httpClient httpIface
+	}
+	type args struct {
+		ctx     context.Context
+		sources []Source
+	}
+	tests := []struct {
+		name     string
+		fields   fields
+		args     args
+		wantData []UserData
+		wantErr  bool
+		mock     func()
+	}{
+		{
+			name: "test1_all_links_are_invalid",
+			args: args{
+				ctx: context.Background(),
+				sources: []Source{
+					{URL: "  "},
+					{URL: "  "},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "test2_all_links_are_down",
+			args: args{
+				ctx: context.Background(),
+				sources: []Source{
+					{URL: "http://localhost:8080"},
+					{URL: "http://localhost:8081"},
+				},
+			},
+			wantErr: true,
+			fields: fields{
+				httpClient: func() httpIface {
+					mock := NewMockhttpIface(mockCtrl)
+					mock.EXPECT().Do(gomock.Any()).Return(&http.Response{
+						StatusCode: http.StatusServiceUnavailable,
+					}, nil).Times(2)
+					return mock
+				}(),
+			},
+		},
+		{
+			name: "test3_all_links_fail_request",
+			args: args{
+				ctx: context.Background(),
+				sources: []Source{
+					{URL: "http://localhost:8080"},
+					{URL: "http://localhost:8081"},
+				},
+			},
+			wantErr: true,
+			fields: fields{
+				httpClient: func() httpIface {
+					mock := NewMockhttpIface(mockCtrl)
+					mock.EXPECT().Do(gomock.Any()).Return(nil, errors.New("err")).Times(2)
+					return mock
+				}(),
+			},
+		},
+		{
+			name: "test4_success_fast_link_wins",
+			args: args{
+				ctx: context.Background(),
+				sources: []Source{
+					{URL: "http://localhost:8090"},
+					{URL: "http://localhost:8091"},
+				},
+			},
+			wantErr: false,
+			wantData: []UserData{
+				{
+					ID:      "12",
+					Balance: "100",
+					Tags:    []string{"tag"},
+				},
+			},
+			fields: fields{
+				httpClient: &http.Client{},
+			},
+			mock: func() {
+				// the slow link only returns once the winner cancels it
+				httpmock.RegisterResponder("GET", "http://localhost:8090", func(req *http.Request) (*http.Response, error) {
+					<-req.Context().Done()
+					return nil, req.Context().Err()
+				},
+				)
+				httpmock.RegisterResponder("GET", "http://localhost:8091", func(req *http.Request) (*http.Response, error) {
+					resp := []UserData{
+						{
+							ID:      "12",
+							Balance: "100",
+							Tags:    []string{"tag"},
+						},
+					}
+					return httpmock.NewJsonResponse(http.StatusOK, resp)
+				},
+				)
+			},
+		},
+		{
+			name: "test5_success_skip_bad_response",
+			args: args{
+				ctx: context.Background(),
+				sources: []Source{
+					{URL: "http://localhost:8092"},
+					{URL: "http://localhost:8093"},
+				},
+			},
+			wantErr: false,
+			wantData: []UserData{
+				{
+					ID:      "12",
+					Balance: "100",
+					Tags:    []string{"tag"},
+				},
+			},
+			fields: fields{
+				httpClient: &http.Client{},
+			},
+			mock: func() {
+				httpmock.RegisterResponder("GET", "http://localhost:8092", func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(http.StatusOK, "not json"), nil
+				},
+				)
+				httpmock.RegisterResponder("GET", "http://localhost:8093", func(req *http.Request) (*http.Response, error) {
+					resp := []UserData{
+						{
+							ID:      "12",
+							Balance: "100",
+							Tags:    []string{"tag"},
+						},
+					}
+					return httpmock.NewJsonResponse(http.StatusOK, resp)
+				},
+				)
+			},
+		},
+	}
+	for _, tt := range tests {
+		if tt.mock != nil {
+			tt.mock()
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			f := &apiFetcher{
+				httpClient: tt.fields.httpClient,
+			}
+			gotData, err := f.getSampleAPIResourceFanOut(tt.args.ctx, tt.args.sources)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("apiFetcher.getSampleAPIResourceFanOut() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotData, tt.wantData) {
+				t.Errorf("apiFetcher.getSampleAPIResourceFanOut() = %v, want %v", gotData, tt.wantData)
+			}
+		})
+	}
+}
+
+func Test_apiFetcher_getSampleAPIResourceMerged(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	type fields struct {
+		httpClient httpIface
+	}
+	type args struct {
+		ctx     context.Context
+		sources []Source
+		rule    MergeRule
+	}
+	tests := []struct {
+		name     string
+		fields   fields
+		args     args
+		wantData []UserData
+		wantErr  bool
+		mock     func()
+	}{
+		{
+			name: "test1_all_links_are_invalid",
+			args: args{
+				ctx: context.Background(),
+				sources: []Source{
+					{URL: "  "},
+					{URL: "  "},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "test2_all_links_are_down",
+			args: args{
+				ctx: context.Background(),
+				sources: []Source{
+					{URL: "http://localhost:8080"},
+					{URL: "http://localhost:8081"},
+				},
+			},
+			wantErr: true,
+			fields: fields{
+				httpClient: func() httpIface {
+					mock := NewMockhttpIface(mockCtrl)
+					mock.EXPECT().Do(gomock.Any()).Return(&http.Response{
+						StatusCode: http.StatusServiceUnavailable,
+					}, nil).Times(2)
+					return mock
+				}(),
+			},
+		},
+		{
+			name: "test3_fail_request",
+			args: args{
+				ctx: context.Background(),
+				sources: []Source{
+					{URL: "http://localhost:8080"},
+				},
+			},
+			wantErr: true,
+			fields: fields{
+				httpClient: func() httpIface {
+					mock := NewMockhttpIface(mockCtrl)
+					mock.EXPECT().Do(gomock.Any()).Return(nil, errors.New("err")).Times(1)
+					return mock
+				}(),
+			},
+		},
+		{
+			name: "test4_success_merge_last_wins",
+			args: args{
+				ctx: context.Background(),
+				sources: []Source{
+					{URL: "http://localhost:8100"},
+					{URL: "http://localhost:8101"},
+					{URL: "http://localhost:8102"},
+				},
+				rule: MergeLastWins,
+			},
+			wantErr: false,
+			wantData: []UserData{
+				{
+					ID:      "12",
+					Balance: "200",
+					Tags:    []string{"tag"},
+				},
+				{
+					ID:      "13",
+					Balance: "100",
+				},
+			},
+			fields: fields{
+				httpClient: &http.Client{},
+			},
+			mock: func() {
+				httpmock.RegisterResponder("GET", "http://localhost:8100", func(req *http.Request) (*http.Response, error) {
+					resp := []UserData{
+						{
+							ID:      "12",
+							Balance: "100",
+							Tags:    []string{"tag"},
+						},
+					}
+					return httpmock.NewJsonResponse(http.StatusOK, resp)
+				},
+				)
+				httpmock.RegisterResponder("GET", "http://localhost:8101", func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewJsonResponse(http.StatusNotFound, nil)
+				},
+				)
+				httpmock.RegisterResponder("GET", "http://localhost:8102", func(req *http.Request) (*http.Response, error) {
+					resp := []UserData{
+						{
+							ID:      "13",
+							Balance: "100",
+						},
+						{
+							ID:      "12",
+							Balance: "200",
+							Tags:    []string{"tag"},
+						},
+					}
+					return httpmock.NewJsonResponse(http.StatusOK, resp)
+				},
+				)
+			},
+		},
+	}
+	for _, tt := range tests {
+		if tt.mock != nil {
+			tt.mock()
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			f := &apiFetcher{
+				httpClient: tt.fields.httpClient,
+			}
+			gotData, err := f.getSampleAPIResourceMerged(tt.args.ctx, tt.args.sources, tt.args.rule)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("apiFetcher.getSampleAPIResourceMerged() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotData, tt.wantData) {
+				t.Errorf("apiFetcher.getSampleAPIResourceMerged() = %v, want %v", gotData, tt.wantData)
+			}
+		})
+	}
+}
+
+func Test_apiFetcher_streamSampleAPIResourceRedirect(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	body := `[{"_id": "1"}, {"_id": "2"}, {"_id": "3"}]`
+	httpmock.RegisterResponder("GET", "http://localhost:8300", httpmock.NewStringResponder(http.StatusOK, body))
+
+	tests := []struct {
+		name     string
+		maxBody  int64
+		wantData []UserData
+		wantErr  error
+	}{
+		{
+			name:     "test1_success",
+			maxBody:  int64(len(body)),
+			wantData: []UserData{{ID: "1"}, {ID: "2"}, {ID: "3"}},
+		},
+		{
+			name:     "test2_body_too_large",
+			maxBody:  int64(len(body)) - 5,
+			wantData: []UserData{{ID: "1"}, {ID: "2"}},
+			wantErr:  ErrBodyTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &apiFetcher{
+				httpClient: &http.Client{},
+				maxBody:    tt.maxBody,
+			}
+			var gotData []UserData
+			err := f.streamSampleAPIResourceRedirect(context.Background(), []Source{{URL: "http://localhost:8300"}}, func(users userSeq) error {
+				return users(func(user UserData) error {
+					gotData = append(gotData, user)
+					return nil
+				})
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("apiFetcher.streamSampleAPIResourceRedirect() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(gotData, tt.wantData) {
+				t.Errorf("apiFetcher.streamSampleAPIResourceRedirect() = %v, want %v", gotData, tt.wantData)
+			}
+		})
+	}
+}
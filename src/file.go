@@ -1,12 +1,25 @@
 package src
 
-import "os"
+import (
+	"errors"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
 
 //go:generate mockgen -destination=file_mock.go -package=src -source=file.go
 type (
 	fReaderIface interface {
 		Create(name string) (*os.File, error)
+		CreateTemp(dir, pattern, modeFrom string) (*os.File, error)
 		Open(name string) (*os.File, error)
+		Rename(oldpath, newpath string) error
+		Remove(name string) error
+		MkdirAll(path string) error
+		ReadDir(name string) ([]os.DirEntry, error)
 	}
 
 	fileHandler struct{}
@@ -16,6 +29,63 @@ func (f *fileHandler) Create(name string) (*os.File, error) {
 	return os.Create(name)
 }
 
+// CreateTemp creates a new file in dir named after pattern like
+// os.CreateTemp. It gets the permissions of the existing file modeFrom, so
+// that replacing a file keeps its mode, or 0666 less the umask like Create
+// when modeFrom does not exist.
+func (f *fileHandler) CreateTemp(dir, pattern, modeFrom string) (*os.File, error) {
+	file, err := createTemp(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(modeFrom)
+	if errors.Is(err, fs.ErrNotExist) {
+		return file, nil
+	}
+	if err == nil {
+		err = file.Chmod(info.Mode().Perm())
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+	return file, nil
+}
+
+// createTemp is os.CreateTemp creating the file with mode 0666 before the
+// umask instead of 0600.
+func createTemp(dir, pattern string) (*os.File, error) {
+	prefix, suffix := pattern, ""
+	if i := strings.LastIndex(pattern, "*"); i >= 0 {
+		prefix, suffix = pattern[:i], pattern[i+1:]
+	}
+	for try := 0; ; try++ {
+		name := filepath.Join(dir, prefix+strconv.FormatUint(uint64(rand.Uint32()), 10)+suffix)
+		file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if errors.Is(err, fs.ErrExist) && try < 10000 {
+			continue
+		}
+		return file, err
+	}
+}
+
 func (f *fileHandler) Open(name string) (*os.File, error) {
 	return os.Open(name)
 }
+
+func (f *fileHandler) Rename(oldpath, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
+func (f *fileHandler) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (f *fileHandler) MkdirAll(path string) error {
+	return os.MkdirAll(path, 0755)
+}
+
+func (f *fileHandler) ReadDir(name string) ([]os.DirEntry, error) {
+	return os.ReadDir(name)
+}
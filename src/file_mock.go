@@ -49,6 +49,35 @@ func (mr *MockfReaderIfaceMockRecorder) Create(name interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockfReaderIface)(nil).Create), name)
 }
 
+// CreateTemp mocks base method.
+func (m *MockfReaderIface) CreateTemp(dir, pattern, modeFrom string) (*os.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTemp", dir, pattern, modeFrom)
+	ret0, _ := ret[0].(*os.File)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTemp indicates an expected call of CreateTemp.
+func (mr *MockfReaderIfaceMockRecorder) CreateTemp(dir, pattern, modeFrom interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTemp", reflect.TypeOf((*MockfReaderIface)(nil).CreateTemp), dir, pattern, modeFrom)
+}
+
+// MkdirAll mocks base method.
+func (m *MockfReaderIface) MkdirAll(path string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MkdirAll", path)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MkdirAll indicates an expected call of MkdirAll.
+func (mr *MockfReaderIfaceMockRecorder) MkdirAll(path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MkdirAll", reflect.TypeOf((*MockfReaderIface)(nil).MkdirAll), path)
+}
+
 // Open mocks base method.
 func (m *MockfReaderIface) Open(name string) (*os.File, error) {
 	m.ctrl.T.Helper()
@@ -63,3 +92,46 @@ func (mr *MockfReaderIfaceMockRecorder) Open(name interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Open", reflect.TypeOf((*MockfReaderIface)(nil).Open), name)
 }
+
+// ReadDir mocks base method.
+func (m *MockfReaderIface) ReadDir(name string) ([]os.DirEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadDir", name)
+	ret0, _ := ret[0].([]os.DirEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadDir indicates an expected call of ReadDir.
+func (mr *MockfReaderIfaceMockRecorder) ReadDir(name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadDir", reflect.TypeOf((*MockfReaderIface)(nil).ReadDir), name)
+}
+
+// Remove mocks base method.
+func (m *MockfReaderIface) Remove(name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Remove", name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Remove indicates an expected call of Remove.
+func (mr *MockfReaderIfaceMockRecorder) Remove(name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockfReaderIface)(nil).Remove), name)
+}
+
+// Rename mocks base method.
+func (m *MockfReaderIface) Rename(oldpath, newpath string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rename", oldpath, newpath)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Rename indicates an expected call of Rename.
+func (mr *MockfReaderIfaceMockRecorder) Rename(oldpath, newpath interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rename", reflect.TypeOf((*MockfReaderIface)(nil).Rename), oldpath, newpath)
+}
@@ -0,0 +1,42 @@
+package src
+
+// Filter selects users during a search. Unset fields are not checked, so the
+// zero Filter matches every user.
+type Filter struct {
+	// Query is matched against the user's tags.
+	Query Query
+	// Active keeps only users with the given ActiveStatus.
+	Active *bool
+	// MinBalance and MaxBalance bound the parsed Balance, both inclusive.
+	MinBalance *Money
+	MaxBalance *Money
+}
+
+// Match reports whether user passes every condition of the filter. tags is
+// the user's tag set. A Balance that cannot be parsed is out of any balance
+// range, so a single malformed row does not abort a balance search.
+func (f Filter) Match(user UserData, tags map[string]struct{}) bool {
+	if f.Query != nil && !f.Query.Match(tags) {
+		return false
+	}
+
+	if f.Active != nil && user.ActiveStatus != *f.Active {
+		return false
+	}
+
+	if f.MinBalance == nil && f.MaxBalance == nil {
+		return true
+	}
+
+	balance, err := ParseMoney(user.Balance)
+	if err != nil {
+		return false
+	}
+	if f.MinBalance != nil && balance < *f.MinBalance {
+		return false
+	}
+	if f.MaxBalance != nil && balance > *f.MaxBalance {
+		return false
+	}
+	return true
+}
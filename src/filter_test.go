@@ -0,0 +1,114 @@
+package src
+
+import "testing"
+
+func TestFilter_Match(t *testing.T) {
+	var (
+		active   = true
+		inactive = false
+		low      = Money(100000)
+		high     = Money(200000)
+	)
+	user := UserData{
+		ID:           "1",
+		ActiveStatus: true,
+		Balance:      "$1,500.00",
+		Tags:         []string{"a"},
+	}
+	tags := map[string]struct{}{"a": {}}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		user   UserData
+		want   bool
+	}{
+		{
+			name: "test1_zero_filter",
+			user: user,
+			want: true,
+		},
+		{
+			name: "test2_query_mismatch",
+			filter: Filter{
+				Query: tagQuery{tag: "b"},
+			},
+			user: user,
+			want: false,
+		},
+		{
+			name: "test3_active",
+			filter: Filter{
+				Query:  tagQuery{tag: "a"},
+				Active: &active,
+			},
+			user: user,
+			want: true,
+		},
+		{
+			name: "test4_inactive",
+			filter: Filter{
+				Active: &inactive,
+			},
+			user: user,
+			want: false,
+		},
+		{
+			name: "test5_in_range",
+			filter: Filter{
+				MinBalance: &low,
+				MaxBalance: &high,
+			},
+			user: user,
+			want: true,
+		},
+		{
+			name: "test6_below_min",
+			filter: Filter{
+				MinBalance: &high,
+			},
+			user: user,
+			want: false,
+		},
+		{
+			name: "test7_above_max",
+			filter: Filter{
+				MaxBalance: &low,
+			},
+			user: user,
+			want: false,
+		},
+		{
+			name: "test8_bounds_inclusive",
+			filter: Filter{
+				MinBalance: &low,
+				MaxBalance: &low,
+			},
+			user: UserData{ID: "2", Balance: "$1,000.00"},
+			want: true,
+		},
+		{
+			name: "test9_bad_balance",
+			filter: Filter{
+				MinBalance: &low,
+			},
+			user: UserData{ID: "3", Balance: "n/a"},
+			want: false,
+		},
+		{
+			name: "test10_bad_balance_not_checked",
+			filter: Filter{
+				Active: &inactive,
+			},
+			user: UserData{ID: "3", Balance: "n/a"},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(tt.user, tags); got != tt.want {
+				t.Errorf("Filter.Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
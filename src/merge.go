@@ -0,0 +1,65 @@
+package src
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MergeRule decides which record is kept when more than one source returns
+// the same UserData.ID.
+type MergeRule int
+
+const (
+	// MergeFirstWins keeps the record from the earliest link in the list.
+	MergeFirstWins MergeRule = iota
+	// MergeLastWins keeps the record from the latest link in the list.
+	MergeLastWins
+)
+
+func (r MergeRule) String() string {
+	switch r {
+	case MergeFirstWins:
+		return "first"
+	case MergeLastWins:
+		return "last"
+	}
+	return fmt.Sprintf("MergeRule(%d)", int(r))
+}
+
+// ParseMergeRule converts "first" or "last" into a MergeRule.
+func ParseMergeRule(s string) (MergeRule, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "first":
+		return MergeFirstWins, nil
+	case "last":
+		return MergeLastWins, nil
+	}
+	return 0, fmt.Errorf("unknown merge rule %q", s)
+}
+
+// mergeUserData flattens the per link results into one slice de-duplicated by
+// ID. results is indexed by link position. A record keeps the position where
+// its ID first appeared.
+func mergeUserData(results [][]UserData, rule MergeRule) []UserData {
+	var (
+		merged = []UserData{}
+		pos    = make(map[string]int)
+	)
+	for _, data := range results {
+		for _, v := range data {
+			i, ok := pos[v.ID]
+			if !ok {
+				pos[v.ID] = len(merged)
+				merged = append(merged, v)
+				continue
+			}
+
+			if rule == MergeFirstWins {
+				continue
+			}
+			merged[i] = v
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,110 @@
+package src
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMergeRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		arg     string
+		want    MergeRule
+		wantErr bool
+	}{
+		{
+			name: "test1_first",
+			arg:  "first",
+			want: MergeFirstWins,
+		},
+		{
+			name: "test2_last",
+			arg:  " Last ",
+			want: MergeLastWins,
+		},
+		{
+			name:    "test3_unknown",
+			arg:     "newest",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMergeRule(tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseMergeRule() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseMergeRule() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_mergeUserData(t *testing.T) {
+	results := [][]UserData{
+		{
+			{ID: "1", Balance: "a1"},
+			{ID: "2", Balance: "a2"},
+		},
+		{
+			{ID: "2", Balance: "b2"},
+			{ID: "3", Balance: "b3"},
+		},
+		{
+			{ID: "1", Balance: "c1"},
+		},
+	}
+	type args struct {
+		results [][]UserData
+		rule    MergeRule
+	}
+	tests := []struct {
+		name string
+		args args
+		want []UserData
+	}{
+		{
+			name: "test1_first_wins",
+			args: args{
+				results: results,
+				rule:    MergeFirstWins,
+			},
+			want: []UserData{
+				{ID: "1", Balance: "a1"},
+				{ID: "2", Balance: "a2"},
+				{ID: "3", Balance: "b3"},
+			},
+		},
+		{
+			name: "test2_last_wins",
+			args: args{
+				results: results,
+				rule:    MergeLastWins,
+			},
+			want: []UserData{
+				{ID: "1", Balance: "c1"},
+				{ID: "2", Balance: "b2"},
+				{ID: "3", Balance: "b3"},
+			},
+		},
+		{
+			name: "test3_skip_missing_results",
+			args: args{
+				results: [][]UserData{nil, {{ID: "1"}}},
+				rule:    MergeFirstWins,
+			},
+			want: []UserData{
+				{ID: "1"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeUserData(tt.args.results, tt.args.rule); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeUserData() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package src
 
+import "fmt"
+
 type (
 	httpResponseGeneral struct {
 		content []byte
@@ -12,4 +14,32 @@ type (
 		Balance      string   `json:"balance"`
 		Tags         []string `json:"tags"`
 	}
+
+	// Report summarizes the content of a stored data file.
+	Report struct {
+		Version  int
+		Rows     int
+		Active   int
+		Inactive int
+		Tags     map[string]int
+		Problems []RowProblem
+	}
+
+	// RowProblem describes why a stored row could not be used. Err is a
+	// *ParseError for rows that cannot be decoded.
+	RowProblem struct {
+		Row int
+		Err error
+	}
 )
+
+func (p RowProblem) Error() string {
+	if parseErr, ok := p.Err.(*ParseError); ok && parseErr.Row == p.Row {
+		return parseErr.Error()
+	}
+	return fmt.Sprintf("row %d: %v", p.Row, p.Err)
+}
+
+func (p RowProblem) Unwrap() error {
+	return p.Err
+}
@@ -0,0 +1,93 @@
+package src
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in cents, parsed from balances such as "$3,141.59".
+type Money int64
+
+// ParseMoney parses an amount with an optional sign, an optional dollar sign,
+// optional thousands separators and at most two decimals, e.g. "$3,141.59",
+// "-$12.5" or "1000".
+func ParseMoney(s string) (Money, error) {
+	str := strings.TrimSpace(s)
+	negative := false
+	if strings.HasPrefix(str, "-") {
+		negative = true
+		str = str[1:]
+	}
+	str = strings.TrimPrefix(str, "$")
+	if !negative && strings.HasPrefix(str, "-") {
+		negative = true
+		str = str[1:]
+	}
+
+	whole, frac := str, ""
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		whole, frac = str[:i], str[i+1:]
+	}
+	whole = strings.ReplaceAll(whole, ",", "")
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("invalid money value %q", s)
+	}
+	if len(frac) > 2 {
+		return 0, fmt.Errorf("invalid money value %q: more than two decimals", s)
+	}
+	if !isDigits(whole) || !isDigits(frac) {
+		return 0, fmt.Errorf("invalid money value %q", s)
+	}
+
+	var dollars, cents int64
+	var err error
+	if whole != "" {
+		dollars, err = strconv.ParseInt(whole, 10, 64)
+		if err != nil || dollars > math.MaxInt64/100-1 {
+			return 0, fmt.Errorf("invalid money value %q: out of range", s)
+		}
+	}
+	if frac != "" {
+		cents, _ = strconv.ParseInt(frac, 10, 64)
+		if len(frac) == 1 {
+			cents *= 10
+		}
+	}
+
+	m := Money(dollars*100 + cents)
+	if negative {
+		m = -m
+	}
+	return m, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the amount the same way balances are served by the sources.
+func (m Money) String() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+
+	whole := strconv.FormatInt(v/100, 10)
+	var sb strings.Builder
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(r)
+	}
+	return fmt.Sprintf("%s$%s.%02d", sign, sb.String(), v%100)
+}
@@ -0,0 +1,111 @@
+package src
+
+import "testing"
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name    string
+		arg     string
+		want    Money
+		wantErr bool
+	}{
+		{
+			name: "test1_source_format",
+			arg:  "$3,141.59",
+			want: 314159,
+		},
+		{
+			name: "test2_plain",
+			arg:  " 1000 ",
+			want: 100000,
+		},
+		{
+			name: "test3_one_decimal",
+			arg:  "$12.5",
+			want: 1250,
+		},
+		{
+			name: "test4_negative_before_dollar",
+			arg:  "-$1,000.01",
+			want: -100001,
+		},
+		{
+			name: "test5_negative_after_dollar",
+			arg:  "$-0.99",
+			want: -99,
+		},
+		{
+			name: "test6_only_cents",
+			arg:  ".5",
+			want: 50,
+		},
+		{
+			name:    "test7_empty",
+			arg:     "$",
+			wantErr: true,
+		},
+		{
+			name:    "test8_too_many_decimals",
+			arg:     "$1.001",
+			wantErr: true,
+		},
+		{
+			name:    "test9_letters",
+			arg:     "$1a.00",
+			wantErr: true,
+		},
+		{
+			name:    "test10_out_of_range",
+			arg:     "99999999999999999999",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMoney(tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseMoney() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseMoney() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Money
+		want string
+	}{
+		{
+			name: "test1_zero",
+			m:    0,
+			want: "$0.00",
+		},
+		{
+			name: "test2_thousands",
+			m:    314159,
+			want: "$3,141.59",
+		},
+		{
+			name: "test3_millions",
+			m:    123456789,
+			want: "$1,234,567.89",
+		},
+		{
+			name: "test4_negative",
+			m:    -5,
+			want: "-$0.05",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.String(); got != tt.want {
+				t.Errorf("Money.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
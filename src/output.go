@@ -0,0 +1,340 @@
+package src
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Output formats accepted by NewUserWriter.
+const (
+	FormatText   = "text"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+	FormatTSV    = "tsv"
+	FormatYAML   = "yaml"
+	FormatTable  = "table"
+)
+
+// Formats lists every output format in the order shown to users.
+var Formats = []string{FormatText, FormatJSON, FormatNDJSON, FormatCSV, FormatTSV, FormatYAML, FormatTable}
+
+// userField describes one selectable column of UserData.
+type userField struct {
+	name  string
+	label string
+	value func(u UserData) interface{}
+}
+
+var userFields = []userField{
+	{
+		name:  "id",
+		label: "ID",
+		value: func(u UserData) interface{} { return u.ID },
+	},
+	{
+		name:  "active",
+		label: "Active",
+		value: func(u UserData) interface{} { return u.ActiveStatus },
+	},
+	{
+		name:  "balance",
+		label: "Balance",
+		value: func(u UserData) interface{} { return u.Balance },
+	},
+	{
+		name:  "tags",
+		label: "Tags",
+		value: func(u UserData) interface{} {
+			if u.Tags == nil {
+				return []string{}
+			}
+			return u.Tags
+		},
+	},
+}
+
+// FieldNames returns the names accepted in the fields list of NewUserWriter.
+func FieldNames() []string {
+	names := make([]string, 0, len(userFields))
+	for _, v := range userFields {
+		names = append(names, v.name)
+	}
+	return names
+}
+
+func selectFields(names []string) ([]userField, error) {
+	if len(names) == 0 {
+		return userFields, nil
+	}
+
+	fields := make([]userField, 0, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		found := false
+		for _, v := range userFields {
+			if v.name == name {
+				fields = append(fields, v)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown field %q, expected one of %s", name, strings.Join(FieldNames(), ", "))
+		}
+	}
+	return fields, nil
+}
+
+// UserWriter encodes users one at a time. Close must be called once all users
+// are written, it completes the document and flushes buffered output.
+type UserWriter interface {
+	Write(u UserData) error
+	Close() error
+}
+
+// NewUserWriter returns a writer producing the given format with the selected
+// fields, in order. No fields selects every field.
+func NewUserWriter(w io.Writer, format string, fields []string) (UserWriter, error) {
+	selected, err := selectFields(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(format) {
+	case FormatText, "":
+		return &textUserWriter{w: bufio.NewWriter(w), fields: selected}, nil
+	case FormatJSON:
+		return &jsonUserWriter{w: bufio.NewWriter(w), fields: selected}, nil
+	case FormatNDJSON:
+		return &jsonUserWriter{w: bufio.NewWriter(w), fields: selected, lines: true}, nil
+	case FormatCSV, FormatTSV:
+		cw := csv.NewWriter(w)
+		if strings.ToLower(format) == FormatTSV {
+			cw.Comma = '\t'
+		}
+		return &csvUserWriter{w: cw, fields: selected}, nil
+	case FormatYAML:
+		return &yamlUserWriter{w: bufio.NewWriter(w), fields: selected}, nil
+	case FormatTable:
+		return &tableUserWriter{w: tabwriter.NewWriter(w, 0, 0, 2, ' ', 0), fields: selected}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q, expected one of %s", format, strings.Join(Formats, ", "))
+}
+
+// fieldString renders a value for the flat formats.
+func fieldString(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case bool:
+		return strconv.FormatBool(val)
+	case []string:
+		return strings.Join(val, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+// textUserWriter prints "ID: x, Balance: y" lines.
+type textUserWriter struct {
+	w      *bufio.Writer
+	fields []userField
+}
+
+func (t *textUserWriter) Write(u UserData) error {
+	for i, f := range t.fields {
+		if i > 0 {
+			t.w.WriteString(", ")
+		}
+		fmt.Fprintf(t.w, "%s: %s", f.label, fieldString(f.value(u)))
+	}
+	_, err := t.w.WriteString("\n")
+	return err
+}
+
+func (t *textUserWriter) Close() error {
+	return t.w.Flush()
+}
+
+// jsonUserWriter prints a JSON array, or one object per line for ndjson.
+type jsonUserWriter struct {
+	w      *bufio.Writer
+	fields []userField
+	lines  bool
+	count  int
+}
+
+func (j *jsonUserWriter) object(u UserData) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range j.fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		val, err := json.Marshal(f.value(u))
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&buf, "%q:", f.name)
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (j *jsonUserWriter) Write(u UserData) error {
+	obj, err := j.object(u)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case j.lines:
+	case j.count == 0:
+		j.w.WriteString("[\n  ")
+	default:
+		j.w.WriteString(",\n  ")
+	}
+	j.count++
+
+	j.w.Write(obj)
+	if j.lines {
+		j.w.WriteByte('\n')
+	}
+	return nil
+}
+
+func (j *jsonUserWriter) Close() error {
+	if !j.lines {
+		if j.count == 0 {
+			j.w.WriteString("[]\n")
+		} else {
+			j.w.WriteString("\n]\n")
+		}
+	}
+	return j.w.Flush()
+}
+
+// csvUserWriter prints a header row followed by one row per user.
+type csvUserWriter struct {
+	w      *csv.Writer
+	fields []userField
+	header bool
+}
+
+func (c *csvUserWriter) writeHeader() error {
+	if c.header {
+		return nil
+	}
+	c.header = true
+
+	names := make([]string, 0, len(c.fields))
+	for _, f := range c.fields {
+		names = append(names, f.name)
+	}
+	return c.w.Write(names)
+}
+
+func (c *csvUserWriter) Write(u UserData) error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	row := make([]string, 0, len(c.fields))
+	for _, f := range c.fields {
+		row = append(row, fieldString(f.value(u)))
+	}
+	return c.w.Write(row)
+}
+
+func (c *csvUserWriter) Close() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// yamlUserWriter prints a YAML sequence of mappings, quoting every string.
+type yamlUserWriter struct {
+	w      *bufio.Writer
+	fields []userField
+	count  int
+}
+
+func (y *yamlUserWriter) Write(u UserData) error {
+	y.count++
+	for i, f := range y.fields {
+		prefix := "  "
+		if i == 0 {
+			prefix = "- "
+		}
+		fmt.Fprintf(y.w, "%s%s: %s\n", prefix, f.name, yamlValue(f.value(u)))
+	}
+	return nil
+}
+
+func (y *yamlUserWriter) Close() error {
+	if y.count == 0 {
+		y.w.WriteString("[]\n")
+	}
+	return y.w.Flush()
+}
+
+func yamlValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return strconv.Quote(val)
+	case bool:
+		return strconv.FormatBool(val)
+	case []string:
+		items := make([]string, 0, len(val))
+		for _, s := range val {
+			items = append(items, strconv.Quote(s))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return strconv.Quote(fmt.Sprint(v))
+}
+
+// tableUserWriter prints aligned columns. Alignment needs every row, so the
+// output is only written on Close.
+type tableUserWriter struct {
+	w      *tabwriter.Writer
+	fields []userField
+	header bool
+}
+
+func (t *tableUserWriter) writeHeader() {
+	if t.header {
+		return
+	}
+	t.header = true
+
+	labels := make([]string, 0, len(t.fields))
+	for _, f := range t.fields {
+		labels = append(labels, strings.ToUpper(f.name))
+	}
+	fmt.Fprintln(t.w, strings.Join(labels, "\t"))
+}
+
+func (t *tableUserWriter) Write(u UserData) error {
+	t.writeHeader()
+	cells := make([]string, 0, len(t.fields))
+	for _, f := range t.fields {
+		cells = append(cells, strings.NewReplacer("\t", " ", "\n", " ").Replace(fieldString(f.value(u))))
+	}
+	_, err := fmt.Fprintln(t.w, strings.Join(cells, "\t"))
+	return err
+}
+
+func (t *tableUserWriter) Close() error {
+	t.writeHeader()
+	return t.w.Flush()
+}
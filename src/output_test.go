@@ -0,0 +1,165 @@
+package src
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNewUserWriter(t *testing.T) {
+	users := []UserData{
+		{
+			ID:           "1",
+			ActiveStatus: true,
+			Balance:      "$1,000.00",
+			Tags:         []string{"a", "b"},
+		},
+		{
+			ID:      "22",
+			Balance: "$5.00",
+		},
+	}
+
+	type args struct {
+		format string
+		fields []string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		users   []UserData
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "test1_text_default_fields",
+			args: args{
+				format: FormatText,
+			},
+			users: users,
+			want:  "ID: 1, Active: true, Balance: $1,000.00, Tags: a,b\nID: 22, Active: false, Balance: $5.00, Tags: \n",
+		},
+		{
+			name: "test2_text_legacy_fields",
+			args: args{
+				format: FormatText,
+				fields: []string{"id", "balance"},
+			},
+			users: users,
+			want:  "ID: 1, Balance: $1,000.00\nID: 22, Balance: $5.00\n",
+		},
+		{
+			name: "test3_json",
+			args: args{
+				format: FormatJSON,
+				fields: []string{"id", "tags"},
+			},
+			users: users,
+			want:  "[\n  {\"id\":\"1\",\"tags\":[\"a\",\"b\"]},\n  {\"id\":\"22\",\"tags\":[]}\n]\n",
+		},
+		{
+			name: "test4_json_empty",
+			args: args{
+				format: FormatJSON,
+			},
+			want: "[]\n",
+		},
+		{
+			name: "test5_ndjson",
+			args: args{
+				format: FormatNDJSON,
+				fields: []string{"id", "active"},
+			},
+			users: users,
+			want:  "{\"id\":\"1\",\"active\":true}\n{\"id\":\"22\",\"active\":false}\n",
+		},
+		{
+			name: "test6_csv",
+			args: args{
+				format: FormatCSV,
+				fields: []string{"balance", "tags", "id"},
+			},
+			users: users,
+			want:  "balance,tags,id\n\"$1,000.00\",\"a,b\",1\n$5.00,,22\n",
+		},
+		{
+			name: "test7_csv_empty_has_header",
+			args: args{
+				format: FormatCSV,
+				fields: []string{"id"},
+			},
+			want: "id\n",
+		},
+		{
+			name: "test8_tsv",
+			args: args{
+				format: FormatTSV,
+				fields: []string{"id", "balance"},
+			},
+			users: users,
+			want:  "id\tbalance\n1\t$1,000.00\n22\t$5.00\n",
+		},
+		{
+			name: "test9_yaml",
+			args: args{
+				format: FormatYAML,
+			},
+			users: users[:1],
+			want:  "- id: \"1\"\n  active: true\n  balance: \"$1,000.00\"\n  tags: [\"a\", \"b\"]\n",
+		},
+		{
+			name: "test10_yaml_empty",
+			args: args{
+				format: FormatYAML,
+			},
+			want: "[]\n",
+		},
+		{
+			name: "test11_table",
+			args: args{
+				format: FormatTable,
+				fields: []string{"id", "balance"},
+			},
+			users: users,
+			want:  "ID  BALANCE\n1   $1,000.00\n22  $5.00\n",
+		},
+		{
+			name: "test12_unknown_format",
+			args: args{
+				format: "xml",
+			},
+			wantErr: true,
+		},
+		{
+			name: "test13_unknown_field",
+			args: args{
+				format: FormatJSON,
+				fields: []string{"id", "email"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewUserWriter(&buf, tt.args.format, tt.args.fields)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewUserWriter() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			for _, v := range tt.users {
+				if err := w.Write(v); err != nil {
+					t.Fatalf("UserWriter.Write() error = %v", err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("UserWriter.Close() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("NewUserWriter() output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
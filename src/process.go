@@ -2,21 +2,30 @@ package src
 
 import (
 	"context"
-	"log"
 	"net/http"
 	"time"
 
 	"github.com/golang/mock/gomock"
 )
 
-var uc usecaseIface
-
 //go:generate mockgen -destination=process_mock.go -package=src -source=process.go
 type (
 	usecaseIface interface {
-		GetSampleAPIResourceRedirect(ctx context.Context, link []string) (data []UserData, err error)
+		GetSampleAPIResourceRedirect(ctx context.Context, sources []Source) (data []UserData, err error)
+		GetSampleAPIResourceFanOut(ctx context.Context, sources []Source) (data []UserData, err error)
+		GetSampleAPIResourceMerged(ctx context.Context, sources []Source, rule MergeRule) (data []UserData, err error)
 		StoreAndReplaceUserDataToCSV(ctx context.Context, data []UserData, path string) (err error)
+		FetchAndStoreUserData(ctx context.Context, sources []Source, path string) (err error)
 		SearchUserWithTags(ctx context.Context, tags []string, path string) (data []UserData, err error)
+		SearchUserWithFilter(ctx context.Context, filter Filter, path string) (data []UserData, err error)
+		SearchUserEach(ctx context.Context, filter Filter, path string, fn func(user UserData) error) (err error)
+		LookupUser(ctx context.Context, id string, path string) (user UserData, found bool, err error)
+		InspectCSV(ctx context.Context, path string) (report Report, err error)
+		MigrateCSV(ctx context.Context, path string) (migrated bool, err error)
+		UpsertUserData(ctx context.Context, data []UserData, path string, policy TombstonePolicy) (result UpsertResult, err error)
+		SnapshotCSV(ctx context.Context, path string, opt SnapshotOptions) (snapshot Snapshot, err error)
+		ListSnapshots(ctx context.Context, path string) (list []Snapshot, err error)
+		DiffCSV(ctx context.Context, from, to string) (diff Diff, err error)
 	}
 
 	usecase struct {
@@ -25,41 +34,92 @@ type (
 	}
 )
 
-func newUsecase() usecaseIface {
-	if uc != nil {
-		return uc
-	}
-
-	api, err := newFetcher(&http.Client{
-		Timeout: 10 * time.Second,
-	})
+func newUsecaseWithStorage(client *http.Client, retry RetryPolicy, maxBody int64, storage storageIface) (usecaseIface, error) {
+	api, err := newFetcher(client, retry, maxBody)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	storage := newStorage()
 
-	u := &usecase{
+	return &usecase{
 		api:     api,
 		storage: storage,
-	}
-	uc = u
-	return u
+	}, nil
 }
 
+// newMockUC makes a mock the usecase of the Client behind the package level
+// functions.
 func newMockUC(mockCtrl *gomock.Controller) *MockusecaseIface {
 	mock := NewMockusecaseIface(mockCtrl)
-	uc = mock
+	SetDefault(&Client{uc: mock, sources: DefaultSources()})
 	return mock
 }
 
-func (u *usecase) GetSampleAPIResourceRedirect(ctx context.Context, link []string) (data []UserData, err error) {
-	return u.api.getSampleAPIResourceRedirect(ctx, link)
+// newMockClient returns a Client of its own over a mock usecase.
+func newMockClient(mockCtrl *gomock.Controller) (*Client, *MockusecaseIface) {
+	mock := NewMockusecaseIface(mockCtrl)
+	return &Client{uc: mock, sources: DefaultSources()}, mock
+}
+
+func (u *usecase) GetSampleAPIResourceRedirect(ctx context.Context, sources []Source) (data []UserData, err error) {
+	return u.api.getSampleAPIResourceRedirect(ctx, sources)
+}
+
+func (u *usecase) GetSampleAPIResourceFanOut(ctx context.Context, sources []Source) (data []UserData, err error) {
+	return u.api.getSampleAPIResourceFanOut(ctx, sources)
+}
+
+func (u *usecase) GetSampleAPIResourceMerged(ctx context.Context, sources []Source, rule MergeRule) (data []UserData, err error) {
+	return u.api.getSampleAPIResourceMerged(ctx, sources, rule)
 }
 
 func (u *usecase) StoreAndReplaceUserDataToCSV(ctx context.Context, data []UserData, path string) (err error) {
-	return u.storage.storeAndReplaceUserDataToCSV(ctx, data, path)
+	return u.storage.storeAndReplace(ctx, data, path)
+}
+
+// FetchAndStoreUserData streams the users of the first source answering
+// straight into the file at path.
+func (u *usecase) FetchAndStoreUserData(ctx context.Context, sources []Source, path string) (err error) {
+	return u.api.streamSampleAPIResourceRedirect(ctx, sources, func(users userSeq) error {
+		return u.storage.storeStream(ctx, users, path)
+	})
 }
 
 func (u *usecase) SearchUserWithTags(ctx context.Context, tags []string, path string) (data []UserData, err error) {
-	return u.storage.searchFromCSV(ctx, tags, path)
+	return u.storage.search(ctx, tags, path)
+}
+
+func (u *usecase) SearchUserWithFilter(ctx context.Context, filter Filter, path string) (data []UserData, err error) {
+	return u.storage.searchWithFilter(ctx, filter, path)
+}
+
+func (u *usecase) SearchUserEach(ctx context.Context, filter Filter, path string, fn func(user UserData) error) (err error) {
+	return u.storage.searchEach(ctx, filter, path, fn)
+}
+
+func (u *usecase) LookupUser(ctx context.Context, id string, path string) (user UserData, found bool, err error) {
+	return u.storage.lookup(ctx, id, path)
+}
+
+func (u *usecase) InspectCSV(ctx context.Context, path string) (report Report, err error) {
+	return u.storage.inspect(ctx, path)
+}
+
+func (u *usecase) MigrateCSV(ctx context.Context, path string) (migrated bool, err error) {
+	return u.storage.migrate(ctx, path)
+}
+
+func (u *usecase) UpsertUserData(ctx context.Context, data []UserData, path string, policy TombstonePolicy) (result UpsertResult, err error) {
+	return u.storage.upsert(ctx, data, path, policy)
+}
+
+func (u *usecase) SnapshotCSV(ctx context.Context, path string, opt SnapshotOptions) (snapshot Snapshot, err error) {
+	return u.storage.snapshot(ctx, path, time.Now(), opt)
+}
+
+func (u *usecase) ListSnapshots(ctx context.Context, path string) (list []Snapshot, err error) {
+	return u.storage.snapshots(ctx, path)
+}
+
+func (u *usecase) DiffCSV(ctx context.Context, from, to string) (diff Diff, err error) {
+	return u.storage.diff(ctx, from, to)
 }
@@ -34,19 +34,168 @@ func (m *MockusecaseIface) EXPECT() *MockusecaseIfaceMockRecorder {
 	return m.recorder
 }
 
+// DiffCSV mocks base method.
+func (m *MockusecaseIface) DiffCSV(ctx context.Context, from, to string) (Diff, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiffCSV", ctx, from, to)
+	ret0, _ := ret[0].(Diff)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiffCSV indicates an expected call of DiffCSV.
+func (mr *MockusecaseIfaceMockRecorder) DiffCSV(ctx, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiffCSV", reflect.TypeOf((*MockusecaseIface)(nil).DiffCSV), ctx, from, to)
+}
+
+// FetchAndStoreUserData mocks base method.
+func (m *MockusecaseIface) FetchAndStoreUserData(ctx context.Context, sources []Source, path string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchAndStoreUserData", ctx, sources, path)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FetchAndStoreUserData indicates an expected call of FetchAndStoreUserData.
+func (mr *MockusecaseIfaceMockRecorder) FetchAndStoreUserData(ctx, sources, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchAndStoreUserData", reflect.TypeOf((*MockusecaseIface)(nil).FetchAndStoreUserData), ctx, sources, path)
+}
+
+// GetSampleAPIResourceFanOut mocks base method.
+func (m *MockusecaseIface) GetSampleAPIResourceFanOut(ctx context.Context, sources []Source) ([]UserData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSampleAPIResourceFanOut", ctx, sources)
+	ret0, _ := ret[0].([]UserData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSampleAPIResourceFanOut indicates an expected call of GetSampleAPIResourceFanOut.
+func (mr *MockusecaseIfaceMockRecorder) GetSampleAPIResourceFanOut(ctx, sources interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSampleAPIResourceFanOut", reflect.TypeOf((*MockusecaseIface)(nil).GetSampleAPIResourceFanOut), ctx, sources)
+}
+
+// GetSampleAPIResourceMerged mocks base method.
+func (m *MockusecaseIface) GetSampleAPIResourceMerged(ctx context.Context, sources []Source, rule MergeRule) ([]UserData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSampleAPIResourceMerged", ctx, sources, rule)
+	ret0, _ := ret[0].([]UserData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSampleAPIResourceMerged indicates an expected call of GetSampleAPIResourceMerged.
+func (mr *MockusecaseIfaceMockRecorder) GetSampleAPIResourceMerged(ctx, sources, rule interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSampleAPIResourceMerged", reflect.TypeOf((*MockusecaseIface)(nil).GetSampleAPIResourceMerged), ctx, sources, rule)
+}
+
 // GetSampleAPIResourceRedirect mocks base method.
-func (m *MockusecaseIface) GetSampleAPIResourceRedirect(ctx context.Context, link []string) ([]UserData, error) {
+func (m *MockusecaseIface) GetSampleAPIResourceRedirect(ctx context.Context, sources []Source) ([]UserData, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetSampleAPIResourceRedirect", ctx, link)
+	ret := m.ctrl.Call(m, "GetSampleAPIResourceRedirect", ctx, sources)
 	ret0, _ := ret[0].([]UserData)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetSampleAPIResourceRedirect indicates an expected call of GetSampleAPIResourceRedirect.
-func (mr *MockusecaseIfaceMockRecorder) GetSampleAPIResourceRedirect(ctx, link interface{}) *gomock.Call {
+func (mr *MockusecaseIfaceMockRecorder) GetSampleAPIResourceRedirect(ctx, sources interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSampleAPIResourceRedirect", reflect.TypeOf((*MockusecaseIface)(nil).GetSampleAPIResourceRedirect), ctx, sources)
+}
+
+// InspectCSV mocks base method.
+func (m *MockusecaseIface) InspectCSV(ctx context.Context, path string) (Report, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InspectCSV", ctx, path)
+	ret0, _ := ret[0].(Report)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InspectCSV indicates an expected call of InspectCSV.
+func (mr *MockusecaseIfaceMockRecorder) InspectCSV(ctx, path interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSampleAPIResourceRedirect", reflect.TypeOf((*MockusecaseIface)(nil).GetSampleAPIResourceRedirect), ctx, link)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InspectCSV", reflect.TypeOf((*MockusecaseIface)(nil).InspectCSV), ctx, path)
+}
+
+// ListSnapshots mocks base method.
+func (m *MockusecaseIface) ListSnapshots(ctx context.Context, path string) ([]Snapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSnapshots", ctx, path)
+	ret0, _ := ret[0].([]Snapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSnapshots indicates an expected call of ListSnapshots.
+func (mr *MockusecaseIfaceMockRecorder) ListSnapshots(ctx, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSnapshots", reflect.TypeOf((*MockusecaseIface)(nil).ListSnapshots), ctx, path)
+}
+
+// LookupUser mocks base method.
+func (m *MockusecaseIface) LookupUser(ctx context.Context, id, path string) (UserData, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LookupUser", ctx, id, path)
+	ret0, _ := ret[0].(UserData)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// LookupUser indicates an expected call of LookupUser.
+func (mr *MockusecaseIfaceMockRecorder) LookupUser(ctx, id, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LookupUser", reflect.TypeOf((*MockusecaseIface)(nil).LookupUser), ctx, id, path)
+}
+
+// MigrateCSV mocks base method.
+func (m *MockusecaseIface) MigrateCSV(ctx context.Context, path string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MigrateCSV", ctx, path)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MigrateCSV indicates an expected call of MigrateCSV.
+func (mr *MockusecaseIfaceMockRecorder) MigrateCSV(ctx, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrateCSV", reflect.TypeOf((*MockusecaseIface)(nil).MigrateCSV), ctx, path)
+}
+
+// SearchUserEach mocks base method.
+func (m *MockusecaseIface) SearchUserEach(ctx context.Context, filter Filter, path string, fn func(UserData) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchUserEach", ctx, filter, path, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SearchUserEach indicates an expected call of SearchUserEach.
+func (mr *MockusecaseIfaceMockRecorder) SearchUserEach(ctx, filter, path, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchUserEach", reflect.TypeOf((*MockusecaseIface)(nil).SearchUserEach), ctx, filter, path, fn)
+}
+
+// SearchUserWithFilter mocks base method.
+func (m *MockusecaseIface) SearchUserWithFilter(ctx context.Context, filter Filter, path string) ([]UserData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchUserWithFilter", ctx, filter, path)
+	ret0, _ := ret[0].([]UserData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchUserWithFilter indicates an expected call of SearchUserWithFilter.
+func (mr *MockusecaseIfaceMockRecorder) SearchUserWithFilter(ctx, filter, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchUserWithFilter", reflect.TypeOf((*MockusecaseIface)(nil).SearchUserWithFilter), ctx, filter, path)
 }
 
 // SearchUserWithTags mocks base method.
@@ -64,6 +213,21 @@ func (mr *MockusecaseIfaceMockRecorder) SearchUserWithTags(ctx, tags, path inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchUserWithTags", reflect.TypeOf((*MockusecaseIface)(nil).SearchUserWithTags), ctx, tags, path)
 }
 
+// SnapshotCSV mocks base method.
+func (m *MockusecaseIface) SnapshotCSV(ctx context.Context, path string, opt SnapshotOptions) (Snapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SnapshotCSV", ctx, path, opt)
+	ret0, _ := ret[0].(Snapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SnapshotCSV indicates an expected call of SnapshotCSV.
+func (mr *MockusecaseIfaceMockRecorder) SnapshotCSV(ctx, path, opt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SnapshotCSV", reflect.TypeOf((*MockusecaseIface)(nil).SnapshotCSV), ctx, path, opt)
+}
+
 // StoreAndReplaceUserDataToCSV mocks base method.
 func (m *MockusecaseIface) StoreAndReplaceUserDataToCSV(ctx context.Context, data []UserData, path string) error {
 	m.ctrl.T.Helper()
@@ -77,3 +241,18 @@ func (mr *MockusecaseIfaceMockRecorder) StoreAndReplaceUserDataToCSV(ctx, data,
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreAndReplaceUserDataToCSV", reflect.TypeOf((*MockusecaseIface)(nil).StoreAndReplaceUserDataToCSV), ctx, data, path)
 }
+
+// UpsertUserData mocks base method.
+func (m *MockusecaseIface) UpsertUserData(ctx context.Context, data []UserData, path string, policy TombstonePolicy) (UpsertResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertUserData", ctx, data, path, policy)
+	ret0, _ := ret[0].(UpsertResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertUserData indicates an expected call of UpsertUserData.
+func (mr *MockusecaseIfaceMockRecorder) UpsertUserData(ctx, data, path, policy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertUserData", reflect.TypeOf((*MockusecaseIface)(nil).UpsertUserData), ctx, data, path, policy)
+}
@@ -3,42 +3,95 @@ package src
 import (
 	"context"
 	"errors"
-	"net/http"
+	"path/filepath"
 	"reflect"
 	"testing"
-	"time"
 
 	"github.com/golang/mock/gomock"
 )
 
-func Test_newUsecase(t *testing.T) {
-	uc = nil
-	mockAPI, _ := newFetcher(&http.Client{
-		Timeout: 10 * time.Second,
-	})
-	mockStorage := newStorage()
+func Test_usecase_GetSampleAPIResourceRedirect(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	type fields struct {
+		api     apiFetcherIface
+		storage storageIface
+	}
+	type args struct {
+		ctx     context.Context
+		sources []Source
+	}
 	tests := []struct {
-		name string
-		want usecaseIface
+		name     string
+		fields   fields
+		args     args
+		wantData []UserData
+		wantErr  bool
 	}{
 		{
 			name: "test1_success",
-			want: &usecase{
-				api:     mockAPI,
-				storage: mockStorage,
+			args: args{
+				ctx:     context.Background(),
+				sources: []Source{{URL: "a"}, {URL: "b"}},
+			},
+			wantData: []UserData{
+				{
+					ID:      "12",
+					Balance: "100",
+					Tags:    []string{"tag"},
+				},
+			},
+			wantErr: false,
+			fields: fields{
+				api: func() apiFetcherIface {
+					mock := NewMockapiFetcherIface(mockCtrl)
+					mock.EXPECT().getSampleAPIResourceRedirect(gomock.Any(), []Source{{URL: "a"}, {URL: "b"}}).Return([]UserData{
+						{
+							ID:      "12",
+							Balance: "100",
+							Tags:    []string{"tag"},
+						},
+					}, nil).Times(1)
+					return mock
+				}(),
+			},
+		},
+		{
+			name: "test2_fail",
+			args: args{
+				ctx:     context.Background(),
+				sources: []Source{{URL: "a"}, {URL: "b"}},
+			},
+			wantErr: true,
+			fields: fields{
+				api: func() apiFetcherIface {
+					mock := NewMockapiFetcherIface(mockCtrl)
+					mock.EXPECT().getSampleAPIResourceRedirect(gomock.Any(), []Source{{URL: "a"}, {URL: "b"}}).Return(nil, errors.New("err")).Times(1)
+					return mock
+				}(),
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := newUsecase(); !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("newUsecase() = %v, want %v", got, tt.want)
+			u := &usecase{
+				api:     tt.fields.api,
+				storage: tt.fields.storage,
+			}
+			gotData, err := u.GetSampleAPIResourceRedirect(tt.args.ctx, tt.args.sources)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("usecase.GetSampleAPIResourceRedirect() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotData, tt.wantData) {
+				t.Errorf("usecase.GetSampleAPIResourceRedirect() = %v, want %v", gotData, tt.wantData)
 			}
 		})
 	}
 }
 
-func Test_usecase_GetSampleAPIResourceRedirect(t *testing.T) {
+func Test_usecase_GetSampleAPIResourceFanOut(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 
@@ -47,8 +100,8 @@ func Test_usecase_GetSampleAPIResourceRedirect(t *testing.T) {
 		storage storageIface
 	}
 	type args struct {
-		ctx  context.Context
-		link []string
+		ctx     context.Context
+		sources []Source
 	}
 	tests := []struct {
 		name     string
@@ -60,8 +113,8 @@ func Test_usecase_GetSampleAPIResourceRedirect(t *testing.T) {
 		{
 			name: "test1_success",
 			args: args{
-				ctx:  context.Background(),
-				link: []string{"a", "b"},
+				ctx:     context.Background(),
+				sources: []Source{{URL: "a"}, {URL: "b"}},
 			},
 			wantData: []UserData{
 				{
@@ -74,7 +127,7 @@ func Test_usecase_GetSampleAPIResourceRedirect(t *testing.T) {
 			fields: fields{
 				api: func() apiFetcherIface {
 					mock := NewMockapiFetcherIface(mockCtrl)
-					mock.EXPECT().getSampleAPIResourceRedirect(gomock.Any(), []string{"a", "b"}).Return([]UserData{
+					mock.EXPECT().getSampleAPIResourceFanOut(gomock.Any(), []Source{{URL: "a"}, {URL: "b"}}).Return([]UserData{
 						{
 							ID:      "12",
 							Balance: "100",
@@ -88,14 +141,14 @@ func Test_usecase_GetSampleAPIResourceRedirect(t *testing.T) {
 		{
 			name: "test2_fail",
 			args: args{
-				ctx:  context.Background(),
-				link: []string{"a", "b"},
+				ctx:     context.Background(),
+				sources: []Source{{URL: "a"}, {URL: "b"}},
 			},
 			wantErr: true,
 			fields: fields{
 				api: func() apiFetcherIface {
 					mock := NewMockapiFetcherIface(mockCtrl)
-					mock.EXPECT().getSampleAPIResourceRedirect(gomock.Any(), []string{"a", "b"}).Return(nil, errors.New("err")).Times(1)
+					mock.EXPECT().getSampleAPIResourceFanOut(gomock.Any(), []Source{{URL: "a"}, {URL: "b"}}).Return(nil, errors.New("err")).Times(1)
 					return mock
 				}(),
 			},
@@ -107,13 +160,97 @@ func Test_usecase_GetSampleAPIResourceRedirect(t *testing.T) {
 				api:     tt.fields.api,
 				storage: tt.fields.storage,
 			}
-			gotData, err := u.GetSampleAPIResourceRedirect(tt.args.ctx, tt.args.link)
+			gotData, err := u.GetSampleAPIResourceFanOut(tt.args.ctx, tt.args.sources)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("usecase.GetSampleAPIResourceRedirect() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("usecase.GetSampleAPIResourceFanOut() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if !reflect.DeepEqual(gotData, tt.wantData) {
-				t.Errorf("usecase.GetSampleAPIResourceRedirect() = %v, want %v", gotData, tt.wantData)
+				t.Errorf("usecase.GetSampleAPIResourceFanOut() = %v, want %v", gotData, tt.wantData)
+			}
+		})
+	}
+}
+
+func Test_usecase_GetSampleAPIResourceMerged(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	type fields struct {
+		api     apiFetcherIface
+		storage storageIface
+	}
+	type args struct {
+		ctx     context.Context
+		sources []Source
+		rule    MergeRule
+	}
+	tests := []struct {
+		name     string
+		fields   fields
+		args     args
+		wantData []UserData
+		wantErr  bool
+	}{
+		{
+			name: "test1_success",
+			args: args{
+				ctx:     context.Background(),
+				sources: []Source{{URL: "a"}, {URL: "b"}},
+				rule:    MergeLastWins,
+			},
+			wantData: []UserData{
+				{
+					ID:      "12",
+					Balance: "100",
+					Tags:    []string{"tag"},
+				},
+			},
+			wantErr: false,
+			fields: fields{
+				api: func() apiFetcherIface {
+					mock := NewMockapiFetcherIface(mockCtrl)
+					mock.EXPECT().getSampleAPIResourceMerged(gomock.Any(), []Source{{URL: "a"}, {URL: "b"}}, MergeLastWins).Return([]UserData{
+						{
+							ID:      "12",
+							Balance: "100",
+							Tags:    []string{"tag"},
+						},
+					}, nil).Times(1)
+					return mock
+				}(),
+			},
+		},
+		{
+			name: "test2_fail",
+			args: args{
+				ctx:     context.Background(),
+				sources: []Source{{URL: "a"}, {URL: "b"}},
+				rule:    MergeLastWins,
+			},
+			wantErr: true,
+			fields: fields{
+				api: func() apiFetcherIface {
+					mock := NewMockapiFetcherIface(mockCtrl)
+					mock.EXPECT().getSampleAPIResourceMerged(gomock.Any(), []Source{{URL: "a"}, {URL: "b"}}, MergeLastWins).Return(nil, errors.New("err")).Times(1)
+					return mock
+				}(),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &usecase{
+				api:     tt.fields.api,
+				storage: tt.fields.storage,
+			}
+			gotData, err := u.GetSampleAPIResourceMerged(tt.args.ctx, tt.args.sources, tt.args.rule)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("usecase.GetSampleAPIResourceMerged() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotData, tt.wantData) {
+				t.Errorf("usecase.GetSampleAPIResourceMerged() = %v, want %v", gotData, tt.wantData)
 			}
 		})
 	}
@@ -153,7 +290,7 @@ func Test_usecase_StoreAndReplaceUserDataToCSV(t *testing.T) {
 			fields: fields{
 				storage: func() storageIface {
 					mock := NewMockstorageIface(mockCtrl)
-					mock.EXPECT().storeAndReplaceUserDataToCSV(gomock.Any(), []UserData{
+					mock.EXPECT().storeAndReplace(gomock.Any(), []UserData{
 						{
 							ID: "12",
 						},
@@ -177,7 +314,7 @@ func Test_usecase_StoreAndReplaceUserDataToCSV(t *testing.T) {
 			fields: fields{
 				storage: func() storageIface {
 					mock := NewMockstorageIface(mockCtrl)
-					mock.EXPECT().storeAndReplaceUserDataToCSV(gomock.Any(), []UserData{
+					mock.EXPECT().storeAndReplace(gomock.Any(), []UserData{
 						{
 							ID: "12",
 						},
@@ -236,7 +373,7 @@ func Test_usecase_SearchUserWithTags(t *testing.T) {
 			fields: fields{
 				storage: func() storageIface {
 					mock := NewMockstorageIface(mockCtrl)
-					mock.EXPECT().searchFromCSV(gomock.Any(), []string{"a", "b"}, "a").Return([]UserData{
+					mock.EXPECT().search(gomock.Any(), []string{"a", "b"}, "a").Return([]UserData{
 						{
 							ID: "12",
 						},
@@ -256,7 +393,7 @@ func Test_usecase_SearchUserWithTags(t *testing.T) {
 			fields: fields{
 				storage: func() storageIface {
 					mock := NewMockstorageIface(mockCtrl)
-					mock.EXPECT().searchFromCSV(gomock.Any(), []string{"a", "b"}, "a").Return(nil, errors.New("err")).Times(1)
+					mock.EXPECT().search(gomock.Any(), []string{"a", "b"}, "a").Return(nil, errors.New("err")).Times(1)
 					return mock
 				}(),
 			},
@@ -279,3 +416,438 @@ func Test_usecase_SearchUserWithTags(t *testing.T) {
 		})
 	}
 }
+
+func Test_usecase_SearchUserWithFilter(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	type fields struct {
+		api     apiFetcherIface
+		storage storageIface
+	}
+	type args struct {
+		ctx    context.Context
+		filter Filter
+		path   string
+	}
+	tests := []struct {
+		name     string
+		fields   fields
+		args     args
+		wantData []UserData
+		wantErr  bool
+	}{
+		{
+			name: "test1_success",
+			args: args{
+				ctx:    context.Background(),
+				filter: Filter{Query: tagQuery{tag: "a"}},
+				path:   "a",
+			},
+			wantData: []UserData{
+				{
+					ID: "12",
+				},
+			},
+			wantErr: false,
+			fields: fields{
+				storage: func() storageIface {
+					mock := NewMockstorageIface(mockCtrl)
+					mock.EXPECT().searchWithFilter(gomock.Any(), Filter{Query: tagQuery{tag: "a"}}, "a").Return([]UserData{
+						{
+							ID: "12",
+						},
+					}, nil).Times(1)
+					return mock
+				}(),
+			},
+		},
+		{
+			name: "test2_fail",
+			args: args{
+				ctx:    context.Background(),
+				filter: Filter{Query: tagQuery{tag: "a"}},
+				path:   "a",
+			},
+			wantErr: true,
+			fields: fields{
+				storage: func() storageIface {
+					mock := NewMockstorageIface(mockCtrl)
+					mock.EXPECT().searchWithFilter(gomock.Any(), Filter{Query: tagQuery{tag: "a"}}, "a").Return(nil, errors.New("err")).Times(1)
+					return mock
+				}(),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &usecase{
+				api:     tt.fields.api,
+				storage: tt.fields.storage,
+			}
+			gotData, err := u.SearchUserWithFilter(tt.args.ctx, tt.args.filter, tt.args.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("usecase.SearchUserWithFilter() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotData, tt.wantData) {
+				t.Errorf("usecase.SearchUserWithFilter() = %v, want %v", gotData, tt.wantData)
+			}
+		})
+	}
+}
+
+func Test_usecase_InspectCSV(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	type fields struct {
+		api     apiFetcherIface
+		storage storageIface
+	}
+	type args struct {
+		ctx  context.Context
+		path string
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		args       args
+		wantReport Report
+		wantErr    bool
+	}{
+		{
+			name: "test1_success",
+			args: args{
+				ctx:  context.Background(),
+				path: "a",
+			},
+			wantReport: Report{
+				Rows: 1,
+			},
+			wantErr: false,
+			fields: fields{
+				storage: func() storageIface {
+					mock := NewMockstorageIface(mockCtrl)
+					mock.EXPECT().inspect(gomock.Any(), "a").Return(Report{
+						Rows: 1,
+					}, nil).Times(1)
+					return mock
+				}(),
+			},
+		},
+		{
+			name: "test2_fail",
+			args: args{
+				ctx:  context.Background(),
+				path: "a",
+			},
+			wantErr: true,
+			fields: fields{
+				storage: func() storageIface {
+					mock := NewMockstorageIface(mockCtrl)
+					mock.EXPECT().inspect(gomock.Any(), "a").Return(Report{}, errors.New("err")).Times(1)
+					return mock
+				}(),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &usecase{
+				api:     tt.fields.api,
+				storage: tt.fields.storage,
+			}
+			gotReport, err := u.InspectCSV(tt.args.ctx, tt.args.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("usecase.InspectCSV() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotReport, tt.wantReport) {
+				t.Errorf("usecase.InspectCSV() = %v, want %v", gotReport, tt.wantReport)
+			}
+		})
+	}
+}
+
+func Test_usecase_MigrateCSV(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	type fields struct {
+		api     apiFetcherIface
+		storage storageIface
+	}
+	type args struct {
+		ctx  context.Context
+		path string
+	}
+	tests := []struct {
+		name         string
+		fields       fields
+		args         args
+		wantMigrated bool
+		wantErr      bool
+	}{
+		{
+			name: "test1_success",
+			args: args{
+				ctx:  context.Background(),
+				path: "a",
+			},
+			wantMigrated: true,
+			fields: fields{
+				storage: func() storageIface {
+					mock := NewMockstorageIface(mockCtrl)
+					mock.EXPECT().migrate(gomock.Any(), "a").Return(true, nil).Times(1)
+					return mock
+				}(),
+			},
+		},
+		{
+			name: "test2_fail",
+			args: args{
+				ctx:  context.Background(),
+				path: "a",
+			},
+			wantErr: true,
+			fields: fields{
+				storage: func() storageIface {
+					mock := NewMockstorageIface(mockCtrl)
+					mock.EXPECT().migrate(gomock.Any(), "a").Return(false, errors.New("err")).Times(1)
+					return mock
+				}(),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &usecase{
+				api:     tt.fields.api,
+				storage: tt.fields.storage,
+			}
+			gotMigrated, err := u.MigrateCSV(tt.args.ctx, tt.args.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("usecase.MigrateCSV() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotMigrated != tt.wantMigrated {
+				t.Errorf("usecase.MigrateCSV() = %v, want %v", gotMigrated, tt.wantMigrated)
+			}
+		})
+	}
+}
+
+func Test_usecase_UpsertUserData(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	type fields struct {
+		api     apiFetcherIface
+		storage storageIface
+	}
+	type args struct {
+		ctx    context.Context
+		data   []UserData
+		path   string
+		policy TombstonePolicy
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		args       args
+		wantResult UpsertResult
+		wantErr    bool
+	}{
+		{
+			name: "test1_success",
+			args: args{
+				ctx:    context.Background(),
+				data:   []UserData{{ID: "1"}},
+				path:   "a",
+				policy: TombstoneDelete,
+			},
+			wantResult: UpsertResult{Added: 1},
+			fields: fields{
+				storage: func() storageIface {
+					mock := NewMockstorageIface(mockCtrl)
+					mock.EXPECT().upsert(gomock.Any(), []UserData{{ID: "1"}}, "a", TombstoneDelete).Return(UpsertResult{Added: 1}, nil).Times(1)
+					return mock
+				}(),
+			},
+		},
+		{
+			name: "test2_fail",
+			args: args{
+				ctx:  context.Background(),
+				path: "a",
+			},
+			wantErr: true,
+			fields: fields{
+				storage: func() storageIface {
+					mock := NewMockstorageIface(mockCtrl)
+					mock.EXPECT().upsert(gomock.Any(), nil, "a", TombstoneKeep).Return(UpsertResult{}, errors.New("err")).Times(1)
+					return mock
+				}(),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &usecase{
+				api:     tt.fields.api,
+				storage: tt.fields.storage,
+			}
+			gotResult, err := u.UpsertUserData(tt.args.ctx, tt.args.data, tt.args.path, tt.args.policy)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("usecase.UpsertUserData() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotResult != tt.wantResult {
+				t.Errorf("usecase.UpsertUserData() = %v, want %v", gotResult, tt.wantResult)
+			}
+		})
+	}
+}
+
+func Test_usecase_DiffCSV(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	tests := []struct {
+		name     string
+		storage  func() storageIface
+		wantDiff Diff
+		wantErr  bool
+	}{
+		{
+			name: "test1_success",
+			storage: func() storageIface {
+				mock := NewMockstorageIface(mockCtrl)
+				mock.EXPECT().diff(gomock.Any(), "a", "b").Return(Diff{Added: []UserData{{ID: "1"}}}, nil).Times(1)
+				return mock
+			},
+			wantDiff: Diff{Added: []UserData{{ID: "1"}}},
+		},
+		{
+			name: "test2_fail",
+			storage: func() storageIface {
+				mock := NewMockstorageIface(mockCtrl)
+				mock.EXPECT().diff(gomock.Any(), "a", "b").Return(Diff{}, ErrMissingFile).Times(1)
+				return mock
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &usecase{
+				storage: tt.storage(),
+			}
+			gotDiff, err := u.DiffCSV(context.Background(), "a", "b")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("usecase.DiffCSV() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotDiff, tt.wantDiff) {
+				t.Errorf("usecase.DiffCSV() = %v, want %v", gotDiff, tt.wantDiff)
+			}
+		})
+	}
+}
+
+func Test_usecase_SnapshotCSV(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mock := NewMockstorageIface(mockCtrl)
+	mock.EXPECT().snapshot(gomock.Any(), "a", gomock.Any(), SnapshotOptions{Keep: 3}).Return(Snapshot{Path: "a.snapshots/x"}, nil).Times(1)
+	u := &usecase{
+		storage: mock,
+	}
+	got, err := u.SnapshotCSV(context.Background(), "a", SnapshotOptions{Keep: 3})
+	if err != nil || got.Path != "a.snapshots/x" {
+		t.Errorf("usecase.SnapshotCSV() = %v, %v", got, err)
+	}
+}
+
+func Test_usecase_SearchUserEach(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	filter := Filter{Query: AllTagsQuery([]string{"a"})}
+	mock := NewMockstorageIface(mockCtrl)
+	mock.EXPECT().searchEach(gomock.Any(), filter, "a", gomock.Any()).DoAndReturn(
+		func(ctx context.Context, filter Filter, path string, fn func(user UserData) error) error {
+			return fn(UserData{ID: "1"})
+		}).Times(1)
+	u := &usecase{
+		storage: mock,
+	}
+
+	var got []UserData
+	err := u.SearchUserEach(context.Background(), filter, "a", func(user UserData) error {
+		got = append(got, user)
+		return nil
+	})
+	if err != nil || !reflect.DeepEqual(got, []UserData{{ID: "1"}}) {
+		t.Errorf("usecase.SearchUserEach() = %v, %v", got, err)
+	}
+}
+
+func Test_usecase_LookupUser(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mock := NewMockstorageIface(mockCtrl)
+	mock.EXPECT().lookup(gomock.Any(), "1", "a").Return(UserData{ID: "1"}, true, nil).Times(1)
+	u := &usecase{
+		storage: mock,
+	}
+
+	got, found, err := u.LookupUser(context.Background(), "1", "a")
+	if err != nil || !found || !reflect.DeepEqual(got, UserData{ID: "1"}) {
+		t.Errorf("usecase.LookupUser() = %v, %v, %v", got, found, err)
+	}
+}
+
+func Test_usecase_FetchAndStoreUserData(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	path := filepath.Join(t.TempDir(), "data.csv")
+	sources := []Source{{URL: "http://localhost:8080"}}
+	stream := func(users userSeq) func(ctx context.Context, sources []Source, fn func(users userSeq) error) error {
+		return func(ctx context.Context, sources []Source, fn func(users userSeq) error) error {
+			return fn(users)
+		}
+	}
+
+	api := NewMockapiFetcherIface(mockCtrl)
+	u := &usecase{
+		api:     api,
+		storage: newStorage(),
+	}
+
+	api.EXPECT().streamSampleAPIResourceRedirect(gomock.Any(), sources, gomock.Any()).DoAndReturn(stream(sliceSeq([]UserData{{ID: "1"}}))).Times(1)
+	if err := u.FetchAndStoreUserData(context.Background(), sources, path); err != nil {
+		t.Fatalf("usecase.FetchAndStoreUserData() error = %v", err)
+	}
+
+	// A response failing midway keeps the previous file.
+	failing := func(yield func(user UserData) error) error {
+		if err := yield(UserData{ID: "2"}); err != nil {
+			return err
+		}
+		return ErrBodyTooLarge
+	}
+	api.EXPECT().streamSampleAPIResourceRedirect(gomock.Any(), sources, gomock.Any()).DoAndReturn(stream(failing)).Times(1)
+	if err := u.FetchAndStoreUserData(context.Background(), sources, path); err != ErrBodyTooLarge {
+		t.Fatalf("usecase.FetchAndStoreUserData() error = %v, want %v", err, ErrBodyTooLarge)
+	}
+
+	got, err := u.storage.search(context.Background(), nil, path)
+	if err != nil || !reflect.DeepEqual(got, []UserData{{ID: "1"}}) {
+		t.Errorf("storage.search() = %v, %v, want the first fetch", got, err)
+	}
+}
@@ -0,0 +1,327 @@
+package src
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Query is a parsed boolean tag expression such as
+//
+//	sed AND (quis OR NOT dolor)
+//
+// evaluated against the tag set of a single row.
+type Query interface {
+	Match(tags map[string]struct{}) bool
+	String() string
+}
+
+type (
+	tagQuery struct {
+		tag string
+	}
+
+	notQuery struct {
+		q Query
+	}
+
+	andQuery struct {
+		left, right Query
+	}
+
+	orQuery struct {
+		left, right Query
+	}
+
+	// matchAllQuery matches every row, it is the query of an empty tag list.
+	matchAllQuery struct{}
+)
+
+func (q tagQuery) Match(tags map[string]struct{}) bool {
+	_, ok := tags[q.tag]
+	return ok
+}
+
+func (q tagQuery) String() string {
+	if q.tag == "" || isQueryKeyword(q.tag) || strings.IndexFunc(q.tag, isQuerySpecial) >= 0 {
+		return strconv.Quote(q.tag)
+	}
+	return q.tag
+}
+
+func (q notQuery) Match(tags map[string]struct{}) bool {
+	return !q.q.Match(tags)
+}
+
+func (q notQuery) String() string {
+	return "NOT " + q.q.String()
+}
+
+func (q andQuery) Match(tags map[string]struct{}) bool {
+	return q.left.Match(tags) && q.right.Match(tags)
+}
+
+func (q andQuery) String() string {
+	return "(" + q.left.String() + " AND " + q.right.String() + ")"
+}
+
+func (q orQuery) Match(tags map[string]struct{}) bool {
+	return q.left.Match(tags) || q.right.Match(tags)
+}
+
+func (q orQuery) String() string {
+	return "(" + q.left.String() + " OR " + q.right.String() + ")"
+}
+
+func (q matchAllQuery) Match(tags map[string]struct{}) bool {
+	return true
+}
+
+func (q matchAllQuery) String() string {
+	return "*"
+}
+
+// AllTagsQuery returns a query matching rows that carry every tag in the
+// list, the semantics of SearchFromCSV. An empty list matches every row.
+func AllTagsQuery(tags []string) Query {
+	var q Query
+	for _, v := range tags {
+		if q == nil {
+			q = tagQuery{tag: v}
+			continue
+		}
+		q = andQuery{left: q, right: tagQuery{tag: v}}
+	}
+	if q == nil {
+		return matchAllQuery{}
+	}
+	return q
+}
+
+// requiredTags returns tags every row matching q must carry, gathered from
+// the top level AND chain. Rows may need more tags than listed, nil means no
+// tag is required.
+func requiredTags(q Query) []string {
+	switch v := q.(type) {
+	case tagQuery:
+		return []string{v.tag}
+	case andQuery:
+		return append(requiredTags(v.left), requiredTags(v.right)...)
+	}
+	return nil
+}
+
+// ParseQuery parses a tag expression. Tags are combined with AND, OR and NOT
+// (case insensitive) and grouped with parentheses. NOT binds tighter than AND,
+// which binds tighter than OR, and two tags next to each other are joined with
+// AND. Tags containing spaces, parentheses or a keyword are written in double
+// quotes.
+func ParseQuery(s string) (Query, error) {
+	tokens, err := lexQuery(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &queryParser{tokens: tokens}
+	if p.peek().kind == queryTokEOF {
+		return nil, errors.New("query: empty query")
+	}
+
+	q, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != queryTokEOF {
+		return nil, fmt.Errorf("query: unexpected %s at position %d", tok, tok.pos)
+	}
+	return q, nil
+}
+
+type queryTokenKind int
+
+const (
+	queryTokEOF queryTokenKind = iota
+	queryTokTag
+	queryTokAnd
+	queryTokOr
+	queryTokNot
+	queryTokLParen
+	queryTokRParen
+)
+
+type queryToken struct {
+	kind queryTokenKind
+	text string
+	pos  int
+}
+
+func (t queryToken) String() string {
+	switch t.kind {
+	case queryTokEOF:
+		return "end of query"
+	case queryTokTag:
+		return fmt.Sprintf("tag %q", t.text)
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+func isQueryKeyword(s string) bool {
+	switch strings.ToUpper(s) {
+	case "AND", "OR", "NOT":
+		return true
+	}
+	return false
+}
+
+func isQuerySpecial(r rune) bool {
+	return unicode.IsSpace(r) || r == '(' || r == ')' || r == '"'
+}
+
+func lexQuery(s string) ([]queryToken, error) {
+	var (
+		tokens []queryToken
+		runes  = []rune(s)
+	)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, queryToken{kind: queryTokLParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, queryToken{kind: queryTokRParen, text: ")", pos: i})
+			i++
+		case r == '"':
+			var (
+				sb     strings.Builder
+				start  = i
+				closed = false
+			)
+			for i++; i < len(runes); i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					sb.WriteRune(runes[i])
+					continue
+				}
+				if runes[i] == '"' {
+					closed = true
+					i++
+					break
+				}
+				sb.WriteRune(runes[i])
+			}
+			if !closed {
+				return nil, fmt.Errorf("query: unterminated quote at position %d", start)
+			}
+			tokens = append(tokens, queryToken{kind: queryTokTag, text: sb.String(), pos: start})
+		default:
+			start := i
+			for i < len(runes) && !isQuerySpecial(runes[i]) {
+				i++
+			}
+			word := string(runes[start:i])
+			tok := queryToken{kind: queryTokTag, text: word, pos: start}
+			switch strings.ToUpper(word) {
+			case "AND":
+				tok.kind = queryTokAnd
+			case "OR":
+				tok.kind = queryTokOr
+			case "NOT":
+				tok.kind = queryTokNot
+			}
+			tokens = append(tokens, tok)
+		}
+	}
+
+	return append(tokens, queryToken{kind: queryTokEOF, pos: len(runes)}), nil
+}
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() queryToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != queryTokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *queryParser) parseOr() (Query, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == queryTokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orQuery{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (Query, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek().kind {
+		case queryTokAnd:
+			p.next()
+		case queryTokTag, queryTokNot, queryTokLParen:
+			// implicit AND between adjacent terms
+		default:
+			return left, nil
+		}
+
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andQuery{left: left, right: right}
+	}
+}
+
+func (p *queryParser) parseNot() (Query, error) {
+	if p.peek().kind == queryTokNot {
+		p.next()
+		q, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notQuery{q: q}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *queryParser) parsePrimary() (Query, error) {
+	tok := p.next()
+	switch tok.kind {
+	case queryTokTag:
+		return tagQuery{tag: tok.text}, nil
+	case queryTokLParen:
+		q, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != queryTokRParen {
+			return nil, fmt.Errorf("query: expected \")\" at position %d, got %s", closing.pos, closing)
+		}
+		return q, nil
+	}
+	return nil, fmt.Errorf("query: unexpected %s at position %d", tok, tok.pos)
+}
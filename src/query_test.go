@@ -0,0 +1,210 @@
+package src
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "test1_empty",
+			query:   "   ",
+			wantErr: true,
+		},
+		{
+			name:  "test2_single_tag",
+			query: "sed",
+			want:  "sed",
+		},
+		{
+			name:  "test3_precedence",
+			query: "sed AND quis OR NOT dolor AND et",
+			want:  "((sed AND quis) OR (NOT dolor AND et))",
+		},
+		{
+			name:  "test4_parentheses",
+			query: "sed AND (quis OR NOT dolor)",
+			want:  "(sed AND (quis OR NOT dolor))",
+		},
+		{
+			name:  "test5_lowercase_keywords_and_implicit_and",
+			query: "sed quis or not not dolor",
+			want:  "((sed AND quis) OR NOT NOT dolor)",
+		},
+		{
+			name:  "test6_quoted_tags",
+			query: `"and" OR "two words" OR "say \"hi\""`,
+			want:  `(("and" OR "two words") OR "say \"hi\"")`,
+		},
+		{
+			name:    "test7_missing_closing_paren",
+			query:   "(sed OR quis",
+			wantErr: true,
+		},
+		{
+			name:    "test8_dangling_operator",
+			query:   "sed AND",
+			wantErr: true,
+		},
+		{
+			name:    "test9_unexpected_closing_paren",
+			query:   "sed)",
+			wantErr: true,
+		},
+		{
+			name:    "test10_unterminated_quote",
+			query:   `"sed`,
+			wantErr: true,
+		},
+		{
+			name:    "test11_leading_operator",
+			query:   "OR sed",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseQuery(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseQuery() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && got.String() != tt.want {
+				t.Errorf("ParseQuery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuery_Match(t *testing.T) {
+	tagSet := func(tags ...string) map[string]struct{} {
+		m := make(map[string]struct{})
+		for _, v := range tags {
+			m[v] = struct{}{}
+		}
+		return m
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		tags  map[string]struct{}
+		want  bool
+	}{
+		{
+			name:  "test1_and_with_or",
+			query: "sed AND (quis OR NOT dolor)",
+			tags:  tagSet("sed", "quis", "dolor"),
+			want:  true,
+		},
+		{
+			name:  "test2_and_with_not",
+			query: "sed AND (quis OR NOT dolor)",
+			tags:  tagSet("sed", "dolor"),
+			want:  false,
+		},
+		{
+			name:  "test3_not_missing_tag",
+			query: "sed AND (quis OR NOT dolor)",
+			tags:  tagSet("sed"),
+			want:  true,
+		},
+		{
+			name:  "test4_or",
+			query: "quis OR dolor",
+			tags:  tagSet("dolor"),
+			want:  true,
+		},
+		{
+			name:  "test5_no_tags",
+			query: "NOT sed",
+			tags:  tagSet(),
+			want:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("ParseQuery() error = %v", err)
+			}
+			if got := q.Match(tt.tags); got != tt.want {
+				t.Errorf("Query.Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllTagsQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+		want string
+	}{
+		{
+			name: "test1_empty_matches_all",
+			want: "*",
+		},
+		{
+			name: "test2_single",
+			tags: []string{"a"},
+			want: "a",
+		},
+		{
+			name: "test3_many",
+			tags: []string{"a", "b", "c"},
+			want: "((a AND b) AND c)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AllTagsQuery(tt.tags).String(); got != tt.want {
+				t.Errorf("AllTagsQuery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_requiredTags(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{
+			name:  "test1_single",
+			query: "a",
+			want:  []string{"a"},
+		},
+		{
+			name:  "test2_and_chain",
+			query: "a b AND (c AND NOT d)",
+			want:  []string{"a", "b", "c"},
+		},
+		{
+			name:  "test3_or",
+			query: "a OR b",
+		},
+		{
+			name:  "test4_not",
+			query: "NOT a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := requiredTags(q); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("requiredTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package src
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how fetchHTTP retries a request that failed on the
+// transport level or returned one of RetryableCodes. The zero value disables
+// retrying.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first one.
+	MaxAttempts int
+	// BaseDelay is the wait before the second attempt, doubled after each retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the computed wait, including waits taken from Retry-After.
+	MaxDelay time.Duration
+	// Jitter randomly shortens each wait by up to this fraction (0 to 1).
+	Jitter float64
+	// RetryableCodes lists the response codes worth another attempt.
+	RetryableCodes []int
+	// RespectRetryAfter uses the Retry-After header when it asks for a longer wait.
+	RespectRetryAfter bool
+}
+
+// DefaultRetryPolicy returns the policy used by the CLI.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+		Jitter:      0.2,
+		RetryableCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		RespectRetryAfter: true,
+	}
+}
+
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+func (p RetryPolicy) shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	for _, v := range p.RetryableCodes {
+		if resp.StatusCode == v {
+			return true
+		}
+	}
+	return false
+}
+
+// delay returns the wait before the next attempt, attempt being the number of
+// attempts already made.
+func (p RetryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	if p.Jitter > 0 {
+		jitter := p.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		d -= time.Duration(rand.Float64() * jitter * float64(d))
+	}
+
+	if p.RespectRetryAfter && resp != nil {
+		if after, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok && after > d {
+			d = after
+		}
+	}
+
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if d < 0 {
+		d = 0
+	}
+	return d
+}
+
+// parseRetryAfter accepts both forms allowed by RFC 9110: delay in seconds or
+// an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	at, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	d := at.Sub(now)
+	if d < 0 {
+		d = 0
+	}
+	return d, true
+}
+
+// doWithRetry wraps httpClient.Do with the fetcher's retry policy. Responses
+// that are about to be retried are drained and closed.
+func (f *apiFetcher) doWithRetry(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		resp, err := f.httpClient.Do(req)
+		if attempt >= f.retry.attempts() || !f.retry.shouldRetry(ctx, resp, err) {
+			return resp, err
+		}
+
+		wait := f.retry.delay(attempt, resp)
+		if resp != nil && resp.Body != nil {
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package src
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+)
+
+func Test_parseRetryAfter(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	type args struct {
+		value string
+	}
+	tests := []struct {
+		name   string
+		args   args
+		want   time.Duration
+		wantOk bool
+	}{
+		{
+			name:   "test1_empty",
+			args:   args{},
+			wantOk: false,
+		},
+		{
+			name: "test2_seconds",
+			args: args{
+				value: "3",
+			},
+			want:   3 * time.Second,
+			wantOk: true,
+		},
+		{
+			name: "test3_negative_seconds",
+			args: args{
+				value: "-3",
+			},
+			wantOk: false,
+		},
+		{
+			name: "test4_http_date",
+			args: args{
+				value: now.Add(10 * time.Second).Format(http.TimeFormat),
+			},
+			want:   10 * time.Second,
+			wantOk: true,
+		},
+		{
+			name: "test5_http_date_in_the_past",
+			args: args{
+				value: now.Add(-10 * time.Second).Format(http.TimeFormat),
+			},
+			want:   0,
+			wantOk: true,
+		},
+		{
+			name: "test6_garbage",
+			args: args{
+				value: "soon",
+			},
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.args.value, now)
+			if ok != tt.wantOk {
+				t.Errorf("parseRetryAfter() ok = %v, want %v", ok, tt.wantOk)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseRetryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_delay(t *testing.T) {
+	type args struct {
+		attempt int
+		resp    *http.Response
+	}
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		args   args
+		want   time.Duration
+	}{
+		{
+			name: "test1_first_retry_uses_base_delay",
+			policy: RetryPolicy{
+				BaseDelay: 100 * time.Millisecond,
+				MaxDelay:  time.Second,
+			},
+			args: args{
+				attempt: 1,
+			},
+			want: 100 * time.Millisecond,
+		},
+		{
+			name: "test2_exponential",
+			policy: RetryPolicy{
+				BaseDelay: 100 * time.Millisecond,
+				MaxDelay:  time.Second,
+			},
+			args: args{
+				attempt: 3,
+			},
+			want: 400 * time.Millisecond,
+		},
+		{
+			name: "test3_capped_by_max_delay",
+			policy: RetryPolicy{
+				BaseDelay: 100 * time.Millisecond,
+				MaxDelay:  time.Second,
+			},
+			args: args{
+				attempt: 10,
+			},
+			want: time.Second,
+		},
+		{
+			name: "test4_retry_after_is_longer",
+			policy: RetryPolicy{
+				BaseDelay:         100 * time.Millisecond,
+				MaxDelay:          5 * time.Second,
+				RespectRetryAfter: true,
+			},
+			args: args{
+				attempt: 1,
+				resp: &http.Response{
+					Header: http.Header{"Retry-After": []string{"2"}},
+				},
+			},
+			want: 2 * time.Second,
+		},
+		{
+			name: "test5_retry_after_ignored",
+			policy: RetryPolicy{
+				BaseDelay: 100 * time.Millisecond,
+				MaxDelay:  5 * time.Second,
+			},
+			args: args{
+				attempt: 1,
+				resp: &http.Response{
+					Header: http.Header{"Retry-After": []string{"2"}},
+				},
+			},
+			want: 100 * time.Millisecond,
+		},
+		{
+			name: "test6_retry_after_capped",
+			policy: RetryPolicy{
+				BaseDelay:         100 * time.Millisecond,
+				MaxDelay:          time.Second,
+				RespectRetryAfter: true,
+			},
+			args: args{
+				attempt: 1,
+				resp: &http.Response{
+					Header: http.Header{"Retry-After": []string{"120"}},
+				},
+			},
+			want: time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.delay(tt.args.attempt, tt.args.resp); got != tt.want {
+				t.Errorf("RetryPolicy.delay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("test7_jitter_stays_in_range", func(t *testing.T) {
+		p := RetryPolicy{
+			BaseDelay: 100 * time.Millisecond,
+			Jitter:    0.5,
+		}
+		for i := 0; i < 100; i++ {
+			got := p.delay(1, nil)
+			if got < 50*time.Millisecond || got > 100*time.Millisecond {
+				t.Fatalf("RetryPolicy.delay() = %v, want between 50ms and 100ms", got)
+			}
+		}
+	})
+}
+
+func Test_apiFetcher_doWithRetry(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	policy := RetryPolicy{
+		MaxAttempts:    3,
+		BaseDelay:      time.Millisecond,
+		MaxDelay:       5 * time.Millisecond,
+		RetryableCodes: []int{http.StatusServiceUnavailable},
+	}
+
+	type fields struct {
+		httpClient httpIface
+		retry      RetryPolicy
+	}
+	tests := []struct {
+		name     string
+		fields   fields
+		ctx      context.Context
+		wantCode int
+		wantErr  bool
+	}{
+		{
+			name: "test1_no_policy_single_attempt",
+			ctx:  context.Background(),
+			fields: fields{
+				httpClient: func() httpIface {
+					mock := NewMockhttpIface(mockCtrl)
+					mock.EXPECT().Do(gomock.Any()).Return(&http.Response{
+						StatusCode: http.StatusServiceUnavailable,
+					}, nil).Times(1)
+					return mock
+				}(),
+			},
+			wantCode: http.StatusServiceUnavailable,
+		},
+		{
+			name: "test2_retry_status_then_success",
+			ctx:  context.Background(),
+			fields: fields{
+				retry: policy,
+				httpClient: func() httpIface {
+					mock := NewMockhttpIface(mockCtrl)
+					gomock.InOrder(
+						mock.EXPECT().Do(gomock.Any()).Return(&http.Response{
+							StatusCode: http.StatusServiceUnavailable,
+						}, nil).Times(1),
+						mock.EXPECT().Do(gomock.Any()).Return(&http.Response{
+							StatusCode: http.StatusOK,
+						}, nil).Times(1),
+					)
+					return mock
+				}(),
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "test3_retry_transport_error_then_success",
+			ctx:  context.Background(),
+			fields: fields{
+				retry: policy,
+				httpClient: func() httpIface {
+					mock := NewMockhttpIface(mockCtrl)
+					gomock.InOrder(
+						mock.EXPECT().Do(gomock.Any()).Return(nil, errors.New("err")).Times(2),
+						mock.EXPECT().Do(gomock.Any()).Return(&http.Response{
+							StatusCode: http.StatusOK,
+						}, nil).Times(1),
+					)
+					return mock
+				}(),
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "test4_attempts_exhausted",
+			ctx:  context.Background(),
+			fields: fields{
+				retry: policy,
+				httpClient: func() httpIface {
+					mock := NewMockhttpIface(mockCtrl)
+					mock.EXPECT().Do(gomock.Any()).Return(nil, errors.New("err")).Times(3)
+					return mock
+				}(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "test5_non_retryable_code",
+			ctx:  context.Background(),
+			fields: fields{
+				retry: policy,
+				httpClient: func() httpIface {
+					mock := NewMockhttpIface(mockCtrl)
+					mock.EXPECT().Do(gomock.Any()).Return(&http.Response{
+						StatusCode: http.StatusNotFound,
+					}, nil).Times(1)
+					return mock
+				}(),
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name: "test6_cancelled_context",
+			ctx: func() context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx
+			}(),
+			fields: fields{
+				retry: policy,
+				httpClient: func() httpIface {
+					mock := NewMockhttpIface(mockCtrl)
+					mock.EXPECT().Do(gomock.Any()).Return(nil, context.Canceled).Times(1)
+					return mock
+				}(),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &apiFetcher{
+				httpClient: tt.fields.httpClient,
+				retry:      tt.fields.retry,
+			}
+			req, _ := http.NewRequestWithContext(tt.ctx, http.MethodGet, "http://localhost:8080", nil)
+			got, err := f.doWithRetry(req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("apiFetcher.doWithRetry() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && got.StatusCode != tt.wantCode {
+				t.Errorf("apiFetcher.doWithRetry() code = %v, want %v", got.StatusCode, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package src
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule decides when a scheduled refresh runs next.
+type Schedule interface {
+	// Next returns the first run strictly after t, or the zero time when the
+	// schedule never runs again.
+	Next(t time.Time) time.Time
+}
+
+// Every returns a Schedule running every d.
+func Every(d time.Duration) Schedule {
+	return interval(d)
+}
+
+type interval time.Duration
+
+func (i interval) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(i))
+}
+
+// scheduleAliases are the cron descriptors accepted by ParseSchedule.
+var scheduleAliases = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+}
+
+// ParseSchedule accepts an interval such as "15m" or "@every 1h30m", a
+// descriptor (@hourly, @daily, @weekly, @monthly), or a cron expression of
+// five fields: minute, hour, day of month, month and day of week (0 or 7 is
+// Sunday). Fields take "*", numbers, ranges "1-5", lists "1,15" and steps
+// "*/15" or "0-30/10". Cron schedules follow the local time zone.
+func ParseSchedule(s string) (Schedule, error) {
+	s = strings.TrimSpace(s)
+	if alias, ok := scheduleAliases[strings.ToLower(s)]; ok {
+		s = alias
+	}
+
+	every := strings.TrimPrefix(s, "@every ")
+	if d, err := time.ParseDuration(strings.TrimSpace(every)); err == nil {
+		if d <= 0 {
+			return nil, fmt.Errorf("invalid schedule %q: interval must be positive", s)
+		}
+		return Every(d), nil
+	}
+	if every != s {
+		return nil, fmt.Errorf("invalid schedule %q: bad interval", s)
+	}
+
+	fields := strings.Fields(s)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: expected an interval or 5 cron fields", s)
+	}
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	var sets [5]bitSet
+	for i, v := range fields {
+		set, err := parseCronField(v, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", s, err)
+		}
+		sets[i] = set
+	}
+	if sets[4].has(7) {
+		sets[4] |= 1
+	}
+
+	return &cronSchedule{
+		minute:  sets[0],
+		hour:    sets[1],
+		dom:     sets[2],
+		month:   sets[3],
+		dow:     sets[4],
+		domStar: fields[2] == "*",
+		dowStar: fields[4] == "*",
+	}, nil
+}
+
+type bitSet uint64
+
+func (b bitSet) has(i int) bool {
+	return b&(1<<uint(i)) != 0
+}
+
+func parseCronField(field string, min, max int) (set bitSet, err error) {
+	for _, item := range strings.Split(field, ",") {
+		rangePart, step := item, 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			rangePart = item[:i]
+			step, err = strconv.Atoi(item[i+1:])
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("bad step in %q", item)
+			}
+		}
+
+		low, high := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			parts := strings.SplitN(rangePart, "-", 2)
+			low, err = strconv.Atoi(parts[0])
+			if err != nil {
+				return 0, fmt.Errorf("bad range %q", item)
+			}
+			high, err = strconv.Atoi(parts[1])
+			if err != nil {
+				return 0, fmt.Errorf("bad range %q", item)
+			}
+		default:
+			low, err = strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("bad value %q", item)
+			}
+			// "5/10" runs from 5 to the end of the range.
+			high = low
+			if rangePart != item {
+				high = max
+			}
+		}
+		if low < min || high > max || low > high {
+			return 0, fmt.Errorf("%q is out of range %d-%d", item, min, max)
+		}
+
+		for i := low; i <= high; i += step {
+			set |= 1 << uint(i)
+		}
+	}
+	return set, nil
+}
+
+type cronSchedule struct {
+	minute, hour, dom, month, dow bitSet
+	// domStar and dowStar record an unrestricted day field: when both day
+	// fields are restricted a day matching either of them runs, like cron.
+	domStar, dowStar bool
+}
+
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	dom, dow := c.dom.has(t.Day()), c.dow.has(int(t.Weekday()))
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+func (c *cronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc).Add(time.Minute)
+
+	// Any valid expression runs within a few years, "0 0 30 2 *" never does.
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !c.month.has(int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case !c.hour.has(t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case !c.minute.has(t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
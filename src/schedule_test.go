@@ -0,0 +1,133 @@
+package src
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	// Wednesday 2024-01-31 09:30:15 UTC.
+	from := time.Date(2024, 1, 31, 9, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		schedule string
+		want     []time.Time
+		wantErr  bool
+	}{
+		{
+			name:     "test1_interval",
+			schedule: "15m",
+			want: []time.Time{
+				time.Date(2024, 1, 31, 9, 45, 15, 0, time.UTC),
+				time.Date(2024, 1, 31, 10, 0, 15, 0, time.UTC),
+			},
+		},
+		{
+			name:     "test2_every",
+			schedule: "@every 1h30m",
+			want:     []time.Time{time.Date(2024, 1, 31, 11, 0, 15, 0, time.UTC)},
+		},
+		{
+			name:     "test3_cron_step",
+			schedule: "*/20 * * * *",
+			want: []time.Time{
+				time.Date(2024, 1, 31, 9, 40, 0, 0, time.UTC),
+				time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC),
+				time.Date(2024, 1, 31, 10, 20, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:     "test4_cron_daily_list",
+			schedule: "0 6,18 * * *",
+			want: []time.Time{
+				time.Date(2024, 1, 31, 18, 0, 0, 0, time.UTC),
+				time.Date(2024, 2, 1, 6, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:     "test5_weekdays_range",
+			schedule: "30 9 * * 1-5",
+			want: []time.Time{
+				time.Date(2024, 2, 1, 9, 30, 0, 0, time.UTC),
+				time.Date(2024, 2, 2, 9, 30, 0, 0, time.UTC),
+				time.Date(2024, 2, 5, 9, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:     "test6_day_of_month_or_sunday",
+			schedule: "0 0 1 * 7",
+			want: []time.Time{
+				time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 2, 11, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:     "test7_leap_day",
+			schedule: "0 12 29 2 *",
+			want: []time.Time{
+				time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC),
+				time.Date(2028, 2, 29, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:     "test8_descriptor",
+			schedule: "@monthly",
+			want:     []time.Time{time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:     "test9_never",
+			schedule: "0 0 30 2 *",
+			want:     []time.Time{{}},
+		},
+		{
+			name:     "test10_negative_interval",
+			schedule: "-5m",
+			wantErr:  true,
+		},
+		{
+			name:     "test11_bad_every",
+			schedule: "@every soon",
+			wantErr:  true,
+		},
+		{
+			name:     "test12_field_count",
+			schedule: "* * * *",
+			wantErr:  true,
+		},
+		{
+			name:     "test13_out_of_range",
+			schedule: "60 * * * *",
+			wantErr:  true,
+		},
+		{
+			name:     "test14_bad_step",
+			schedule: "*/0 * * * *",
+			wantErr:  true,
+		},
+		{
+			name:     "test15_reversed_range",
+			schedule: "* 5-1 * * *",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSchedule(tt.schedule)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSchedule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			at := from
+			for _, want := range tt.want {
+				at = got.Next(at)
+				if !at.Equal(want) {
+					t.Fatalf("ParseSchedule().Next() = %v, want %v", at, want)
+				}
+			}
+		})
+	}
+}
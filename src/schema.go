@@ -0,0 +1,165 @@
+package src
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// SchemaVersion is the version of the stored user layout, recorded by CSV
+// files and kv databases. The JSON formats name every field instead.
+//
+// Stored CSV files start with a marker record holding the schema version,
+// followed by a header record naming the columns:
+//
+//	#schema,2
+//	id,active,balance,tags
+//
+// Files written before the marker existed have neither record and use the
+// positional columns of schema version 1.
+const (
+	SchemaVersion = 2
+
+	csvSchemaMarker = "#schema"
+)
+
+// csvColumns are the columns written by the current schema, in order.
+var csvColumns = []string{"id", "active", "balance", "tags"}
+
+// csvHeader returns the marker and header records of the current schema.
+func csvHeader() [][]string {
+	return [][]string{
+		{csvSchemaMarker, strconv.Itoa(SchemaVersion)},
+		csvColumns,
+	}
+}
+
+// csvUserReader reads users from a stored file of any known schema version,
+// mapping columns by their header name so columns may be added or reordered.
+type csvUserReader struct {
+	r       csvReaderIface
+	version int
+	columns map[string]int
+	pending []string
+	row     int
+}
+
+// newCSVUserReader reads the marker and header records, if any. A file
+// without a marker is read as schema version 1.
+func newCSVUserReader(r csvReaderIface) (*csvUserReader, error) {
+	u := &csvUserReader{r: r, version: SchemaVersion, columns: make(map[string]int)}
+
+	first, err := r.Read()
+	if err == io.EOF {
+		u.setColumns(csvColumns)
+		return u, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	u.row++
+
+	if len(first) == 0 || first[0] != csvSchemaMarker {
+		u.version = 1
+		u.setColumns(csvColumns)
+		u.pending = first
+		return u, nil
+	}
+
+	if len(first) < 2 {
+		return nil, fmt.Errorf("%w: missing version", ErrBadSchema)
+	}
+	u.version, err = strconv.Atoi(strings.TrimSpace(first[1]))
+	if err != nil || u.version < 2 {
+		return nil, fmt.Errorf("%w: bad version %q", ErrBadSchema, first[1])
+	}
+	if u.version > SchemaVersion {
+		return nil, fmt.Errorf("%w %d, the newest supported is %d", ErrUnsupportedSchema, u.version, SchemaVersion)
+	}
+
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: missing header", ErrBadSchema)
+	}
+	if err != nil {
+		return nil, err
+	}
+	u.row++
+	u.setColumns(header)
+	for _, v := range csvColumns {
+		if _, ok := u.columns[v]; !ok {
+			return nil, fmt.Errorf("%w: missing column %q", ErrBadSchema, v)
+		}
+	}
+
+	return u, nil
+}
+
+func (u *csvUserReader) setColumns(names []string) {
+	for i, v := range names {
+		name := strings.ToLower(strings.TrimSpace(v))
+		if _, ok := u.columns[name]; !ok {
+			u.columns[name] = i
+		}
+	}
+}
+
+// Read returns the next record along with its record number in the file,
+// counting the marker and header records.
+func (u *csvUserReader) Read() (record []string, row int, err error) {
+	if u.pending != nil {
+		record, u.pending = u.pending, nil
+		return record, u.row, nil
+	}
+
+	record, err = u.r.Read()
+	if err == io.EOF {
+		return nil, u.row, err
+	}
+	u.row++
+	return record, u.row, err
+}
+
+// parse converts a record into the UserData it was written from.
+func (u *csvUserReader) parse(record []string) (user UserData, err error) {
+	value := func(name string) (string, bool) {
+		i := u.columns[name]
+		if i >= len(record) {
+			return "", false
+		}
+		return record[i], true
+	}
+
+	id, ok1 := value("id")
+	active, ok2 := value("active")
+	balance, ok3 := value("balance")
+	tags, ok4 := value("tags")
+	if !ok1 || !ok2 || !ok3 || !ok4 {
+		return user, &ParseError{Err: ErrBadRow}
+	}
+
+	user.ID = id
+	user.ActiveStatus, err = strconv.ParseBool(active)
+	if err != nil {
+		return user, &ParseError{Column: "active", Err: fmt.Errorf("invalid active status %q", active)}
+	}
+
+	user.Balance = balance
+	err = json.Unmarshal([]byte(tags), &user.Tags)
+	if err != nil {
+		return user, &ParseError{Column: "tags", Err: fmt.Errorf("invalid tags: %w", err)}
+	}
+
+	return user, nil
+}
+
+// csvRecord converts a user into a record of the current schema.
+func csvRecord(u UserData) ([]string, error) {
+	tagBytes, err := json.Marshal(&u.Tags)
+	if err != nil {
+		return nil, err
+	}
+	return []string{u.ID, strconv.FormatBool(u.ActiveStatus), u.Balance, string(tagBytes)}, nil
+}
@@ -0,0 +1,180 @@
+package src
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_csvUserReader(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		wantVersion int
+		wantData    []UserData
+		wantRows    []int
+		wantErr     bool
+	}{
+		{
+			name:        "test1_empty",
+			content:     "",
+			wantVersion: SchemaVersion,
+		},
+		{
+			name:        "test2_headerless_v1",
+			content:     "1,true,$1.00,\"[\"\"a\"\"]\"\n2,false,$2.00,[]\n",
+			wantVersion: 1,
+			wantData: []UserData{
+				{ID: "1", ActiveStatus: true, Balance: "$1.00", Tags: []string{"a"}},
+				{ID: "2", Balance: "$2.00", Tags: []string{}},
+			},
+			wantRows: []int{1, 2},
+		},
+		{
+			name:        "test3_current",
+			content:     "#schema,2\nid,active,balance,tags\n1,true,$1.00,\"[\"\"a\"\"]\"\n",
+			wantVersion: 2,
+			wantData: []UserData{
+				{ID: "1", ActiveStatus: true, Balance: "$1.00", Tags: []string{"a"}},
+			},
+			wantRows: []int{3},
+		},
+		{
+			name:        "test4_reordered_and_extra_columns",
+			content:     "#schema,2\nTags,name,ID,balance,active\n[],alice,1,$1.00,true\n",
+			wantVersion: 2,
+			wantData: []UserData{
+				{ID: "1", ActiveStatus: true, Balance: "$1.00", Tags: []string{}},
+			},
+			wantRows: []int{3},
+		},
+		{
+			name:    "test5_newer_version",
+			content: "#schema,3\nid,active,balance,tags\n",
+			wantErr: true,
+		},
+		{
+			name:    "test6_bad_version",
+			content: "#schema,x\nid,active,balance,tags\n",
+			wantErr: true,
+		},
+		{
+			name:    "test7_missing_column",
+			content: "#schema,2\nid,active,balance\n",
+			wantErr: true,
+		},
+		{
+			name:    "test8_missing_header",
+			content: "#schema,2\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := newCSVUserReader((&csvHandler{}).NewReader(strings.NewReader(tt.content)))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newCSVUserReader() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if r.version != tt.wantVersion {
+				t.Errorf("newCSVUserReader() version = %d, want %d", r.version, tt.wantVersion)
+			}
+
+			var (
+				data []UserData
+				rows []int
+			)
+			for {
+				record, row, err := r.Read()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("csvUserReader.Read() error = %v", err)
+				}
+				user, err := r.parse(record)
+				if err != nil {
+					t.Fatalf("csvUserReader.parse() error = %v", err)
+				}
+				data = append(data, user)
+				rows = append(rows, row)
+			}
+			if !reflect.DeepEqual(data, tt.wantData) {
+				t.Errorf("csvUserReader data = %v, want %v", data, tt.wantData)
+			}
+			if !reflect.DeepEqual(rows, tt.wantRows) {
+				t.Errorf("csvUserReader rows = %v, want %v", rows, tt.wantRows)
+			}
+		})
+	}
+}
+
+func Test_storage_migrate(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	s := newStorage()
+
+	tests := []struct {
+		name         string
+		path         string
+		wantMigrated bool
+		wantContent  string
+		wantErr      bool
+	}{
+		{
+			name:    "test1_missing_file",
+			path:    filepath.Join(dir, "missing.csv"),
+			wantErr: true,
+		},
+		{
+			name:         "test2_v1",
+			path:         write("v1.csv", "1,true,$1.00,\"[\"\"a\"\"]\"\n"),
+			wantMigrated: true,
+			wantContent:  "#schema,2\nid,active,balance,tags\n1,true,$1.00,\"[\"\"a\"\"]\"\n",
+		},
+		{
+			name:        "test3_current",
+			path:        write("v2.csv", "#schema,2\nid,active,balance,tags\n"),
+			wantContent: "#schema,2\nid,active,balance,tags\n",
+		},
+		{
+			name:        "test4_bad_row",
+			path:        write("bad.csv", "1,yes,$1.00,[]\n"),
+			wantErr:     true,
+			wantContent: "1,yes,$1.00,[]\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotMigrated, err := s.migrate(context.Background(), tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("storage.migrate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if gotMigrated != tt.wantMigrated {
+				t.Errorf("storage.migrate() = %v, want %v", gotMigrated, tt.wantMigrated)
+			}
+			if tt.wantContent == "" {
+				return
+			}
+			content, err := ioutil.ReadFile(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.wantContent {
+				t.Errorf("storage.migrate() content = %q, want %q", content, tt.wantContent)
+			}
+		})
+	}
+}
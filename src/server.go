@@ -0,0 +1,267 @@
+package src
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ServerOptions configures NewHandler.
+type ServerOptions struct {
+	// Path is the data file served.
+	Path string
+	// Sources are fetched by POST /refresh, the sources of the Client when
+	// empty.
+	Sources []Source
+	// Refresh replaces the default POST /refresh, which streams Sources into
+	// Path like StoreFromSource.
+	Refresh func(ctx context.Context) error
+}
+
+// server serves the data file through a Client, see Client.Handler.
+type server struct {
+	client  *Client
+	path    string
+	refresh func(ctx context.Context) error
+	// refreshing lets a single refresh run at a time.
+	refreshing sync.Mutex
+}
+
+// NewHandler returns the Handler of the Client behind the package level
+// functions, see Default.
+func NewHandler(opt ServerOptions) http.Handler {
+	return Default().Handler(opt)
+}
+
+// Handler returns a JSON API over the data file at opt.Path:
+//
+//	GET  /users?tags=a,b&active=true  users matching the filter, see below
+//	GET  /users/{id}                  a single user
+//	POST /refresh                     fetch from the sources again
+//
+// GET /users accepts the conditions of the search command as parameters:
+// tags, query, active, min-balance and max-balance, plus limit. Users are
+// printed with the fields of the json output format.
+func (c *Client) Handler(opt ServerOptions) http.Handler {
+	s := &server{
+		client:  c,
+		path:    opt.Path,
+		refresh: opt.Refresh,
+	}
+	if s.refresh == nil {
+		fetcher := c.WithSources(opt.Sources...)
+		s.refresh = func(ctx context.Context) error {
+			return fetcher.FetchAndStore(ctx, s.path)
+		}
+	}
+	return s
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/users":
+		if allowMethod(w, r, http.MethodGet) {
+			s.searchUsers(w, r)
+		}
+	case strings.HasPrefix(r.URL.Path, "/users/"):
+		if allowMethod(w, r, http.MethodGet) {
+			s.getUser(w, r, strings.TrimPrefix(r.URL.Path, "/users/"))
+		}
+	case r.URL.Path == "/refresh":
+		if allowMethod(w, r, http.MethodPost) {
+			s.refreshUsers(w, r)
+		}
+	default:
+		writeJSONError(w, http.StatusNotFound, errors.New("not found"))
+	}
+}
+
+func (s *server) searchUsers(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	filter, err := filterFromParams(params)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	limit := 0
+	if v := params.Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			writeJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid limit %q", v))
+			return
+		}
+	}
+
+	// Users are buffered by the writer, the status can still change as long
+	// as nothing was flushed to w.
+	lw := &lazyHeaderWriter{w: w}
+	writer, err := NewUserWriter(lw, FormatJSON, nil)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	count := 0
+	err = s.client.SearchEach(r.Context(), filter, s.path, func(user UserData) error {
+		if err := writer.Write(user); err != nil {
+			return err
+		}
+		count++
+		if limit > 0 && count >= limit {
+			return ErrStopSearch
+		}
+		return nil
+	})
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		if lw.written {
+			log.Printf("GET %s: %v", r.URL, err)
+			return
+		}
+		writeJSONError(w, searchErrorStatus(err), err)
+	}
+}
+
+func (s *server) getUser(w http.ResponseWriter, r *http.Request, id string) {
+	if id == "" || strings.Contains(id, "/") {
+		writeJSONError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+
+	user, found, err := s.client.Lookup(r.Context(), id, s.path)
+	if err != nil {
+		writeJSONError(w, searchErrorStatus(err), err)
+		return
+	}
+	if !found {
+		writeJSONError(w, http.StatusNotFound, fmt.Errorf("user %q not found", id))
+		return
+	}
+
+	writer, err := NewUserWriter(w, FormatNDJSON, nil)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := writer.Write(user); err != nil {
+		log.Printf("GET %s: %v", r.URL, err)
+		return
+	}
+	if err := writer.Close(); err != nil {
+		log.Printf("GET %s: %v", r.URL, err)
+	}
+}
+
+func (s *server) refreshUsers(w http.ResponseWriter, r *http.Request) {
+	if !s.refreshing.TryLock() {
+		writeJSONError(w, http.StatusConflict, errors.New("a refresh is already running"))
+		return
+	}
+	defer s.refreshing.Unlock()
+
+	if err := s.refresh(r.Context()); err != nil {
+		writeJSONError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "refreshed"})
+}
+
+// filterFromParams builds the filter of GET /users, mirroring the flags of
+// the search command.
+func filterFromParams(params url.Values) (filter Filter, err error) {
+	tags, query := params.Get("tags"), params.Get("query")
+	if tags != "" && query != "" {
+		return filter, errors.New("tags and query cannot be used together")
+	}
+
+	var tagList []string
+	for _, v := range strings.Split(tags, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			tagList = append(tagList, v)
+		}
+	}
+	filter.Query = AllTagsQuery(tagList)
+	if query != "" {
+		filter.Query, err = ParseQuery(query)
+		if err != nil {
+			return filter, err
+		}
+	}
+
+	if v := params.Get("active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid active value %q", v)
+		}
+		filter.Active = &active
+	}
+
+	if v := params.Get("min-balance"); v != "" {
+		low, err := ParseMoney(v)
+		if err != nil {
+			return filter, err
+		}
+		filter.MinBalance = &low
+	}
+
+	if v := params.Get("max-balance"); v != "" {
+		high, err := ParseMoney(v)
+		if err != nil {
+			return filter, err
+		}
+		filter.MaxBalance = &high
+	}
+
+	return filter, nil
+}
+
+func searchErrorStatus(err error) int {
+	if errors.Is(err, ErrMissingFile) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// allowMethod answers 405 unless r uses method.
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeJSONError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+// lazyHeaderWriter sets the JSON content type and sends the status on the
+// first write, leaving the status open until then.
+type lazyHeaderWriter struct {
+	w       http.ResponseWriter
+	written bool
+}
+
+func (l *lazyHeaderWriter) Write(p []byte) (int, error) {
+	if !l.written {
+		l.written = true
+		l.w.Header().Set("Content-Type", "application/json")
+	}
+	return l.w.Write(p)
+}
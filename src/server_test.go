@@ -0,0 +1,164 @@
+package src
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func Test_server(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.csv")
+	u := &usecase{storage: newStorage()}
+	err := u.StoreAndReplaceUserDataToCSV(context.Background(), []UserData{
+		{ID: "1", ActiveStatus: true, Balance: "$1.00", Tags: []string{"a", "b"}},
+		{ID: "2", Balance: "$2.00", Tags: []string{"a"}},
+		{ID: "3", ActiveStatus: true, Balance: "$3.00", Tags: []string{"a"}},
+		{ID: "x/y", Balance: "$4.00"},
+	}, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refreshed := 0
+	handler := &server{
+		client: &Client{uc: u},
+		path:   path,
+		refresh: func(ctx context.Context) error {
+			refreshed++
+			return nil
+		},
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "test1_search_tags_active",
+			method:   http.MethodGet,
+			target:   "/users?tags=a&active=true",
+			wantCode: http.StatusOK,
+			wantBody: "[\n  {\"id\":\"1\",\"active\":true,\"balance\":\"$1.00\",\"tags\":[\"a\",\"b\"]},\n  {\"id\":\"3\",\"active\":true,\"balance\":\"$3.00\",\"tags\":[\"a\"]}\n]\n",
+		},
+		{
+			name:     "test2_search_limit",
+			method:   http.MethodGet,
+			target:   "/users?query=a+AND+NOT+b&limit=1",
+			wantCode: http.StatusOK,
+			wantBody: "[\n  {\"id\":\"2\",\"active\":false,\"balance\":\"$2.00\",\"tags\":[\"a\"]}\n]\n",
+		},
+		{
+			name:     "test3_search_no_match",
+			method:   http.MethodGet,
+			target:   "/users?tags=z",
+			wantCode: http.StatusOK,
+			wantBody: "[]\n",
+		},
+		{
+			name:     "test4_search_bad_filter",
+			method:   http.MethodGet,
+			target:   "/users?active=maybe",
+			wantCode: http.StatusBadRequest,
+			wantBody: "{\"error\":\"invalid active value \\\"maybe\\\"\"}\n",
+		},
+		{
+			name:     "test5_get_user",
+			method:   http.MethodGet,
+			target:   "/users/3",
+			wantCode: http.StatusOK,
+			wantBody: "{\"id\":\"3\",\"active\":true,\"balance\":\"$3.00\",\"tags\":[\"a\"]}\n",
+		},
+		{
+			name:     "test6_get_missing_user",
+			method:   http.MethodGet,
+			target:   "/users/9",
+			wantCode: http.StatusNotFound,
+			wantBody: "{\"error\":\"user \\\"9\\\" not found\"}\n",
+		},
+		{
+			name:     "test7_id_with_slash",
+			method:   http.MethodGet,
+			target:   "/users/x/y",
+			wantCode: http.StatusNotFound,
+			wantBody: "{\"error\":\"not found\"}\n",
+		},
+		{
+			name:     "test8_method_not_allowed",
+			method:   http.MethodDelete,
+			target:   "/users/1",
+			wantCode: http.StatusMethodNotAllowed,
+			wantBody: "{\"error\":\"method DELETE not allowed\"}\n",
+		},
+		{
+			name:     "test9_refresh",
+			method:   http.MethodPost,
+			target:   "/refresh",
+			wantCode: http.StatusOK,
+			wantBody: "{\"status\":\"refreshed\"}\n",
+		},
+		{
+			name:     "test10_unknown_route",
+			method:   http.MethodGet,
+			target:   "/",
+			wantCode: http.StatusNotFound,
+			wantBody: "{\"error\":\"not found\"}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+			if rec.Code != tt.wantCode {
+				t.Errorf("server.ServeHTTP() code = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if got := rec.Body.String(); got != tt.wantBody {
+				t.Errorf("server.ServeHTTP() body = %q, want %q", got, tt.wantBody)
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("server.ServeHTTP() content type = %q", got)
+			}
+		})
+	}
+	if refreshed != 1 {
+		t.Errorf("server.refresh called %d times, want 1", refreshed)
+	}
+}
+
+func Test_server_errors(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	client, mock := newMockClient(mockCtrl)
+	handler := client.Handler(ServerOptions{Path: "data.csv"}).(*server)
+
+	mock.EXPECT().SearchUserEach(gomock.Any(), gomock.Any(), "data.csv", gomock.Any()).Return(ErrMissingFile).Times(1)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET /users code = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	mock.EXPECT().FetchAndStoreUserData(gomock.Any(), DefaultSources(), "data.csv").Return(errors.New("all links are down")).Times(1)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/refresh", nil))
+	if rec.Code != http.StatusBadGateway || !strings.Contains(rec.Body.String(), "all links are down") {
+		t.Errorf("POST /refresh = %d %s", rec.Code, rec.Body.String())
+	}
+
+	// A second refresh is refused while one is running.
+	handler.refreshing.Lock()
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/refresh", nil))
+	handler.refreshing.Unlock()
+	if rec.Code != http.StatusConflict {
+		t.Errorf("POST /refresh code = %d, want %d", rec.Code, http.StatusConflict)
+	}
+}
@@ -0,0 +1,219 @@
+package src
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// snapshotTimeLayout names snapshot files, it has a fixed width so names sort
+// like their times.
+const snapshotTimeLayout = "20060102T150405.000000000Z"
+
+type (
+	// Snapshot is a timestamped copy of a data file, kept in SnapshotDir.
+	Snapshot struct {
+		Path string    `json:"path"`
+		Time time.Time `json:"time"`
+	}
+
+	// SnapshotOptions configures how a snapshot is taken.
+	SnapshotOptions struct {
+		// Keep is the number of snapshots kept, the oldest ones beyond it are
+		// removed. 0 keeps every snapshot.
+		Keep int
+		// Compression compresses the snapshot of an uncompressed CSV or JSON
+		// data file, one of Compressions. Empty copies the data file as is.
+		Compression string
+	}
+)
+
+// Name returns the file name of the snapshot.
+func (s Snapshot) Name() string {
+	return filepath.Base(s.Path)
+}
+
+// SnapshotDir returns the directory holding the snapshots of the data file at
+// path.
+func SnapshotDir(path string) string {
+	return path + ".snapshots"
+}
+
+// snapshotExt returns the extensions of path from the first dot of its base
+// name on, so that "data.csv.gz" keeps ".csv.gz" and snapshots are read in
+// the same format as path.
+func snapshotExt(path string) string {
+	base := strings.TrimLeft(filepath.Base(path), ".")
+	if i := strings.Index(base, "."); i >= 0 {
+		return base[i:]
+	}
+	return ""
+}
+
+// snapshot copies the data file at path into SnapshotDir, named after at, and
+// then removes the oldest snapshots beyond opt.Keep.
+func (s *storage) snapshot(ctx context.Context, path string, at time.Time, opt SnapshotOptions) (snapshot Snapshot, err error) {
+	if path == "" {
+		path = "data.csv"
+	}
+
+	compression, err := ParseCompression(opt.Compression)
+	if err != nil {
+		return snapshot, err
+	}
+	ext := snapshotExt(path)
+	if current, _ := compressionFromPath(path); current != "" {
+		// Already compressed, the bytes are copied as they are.
+		compression = ""
+	}
+	if compression != "" {
+		format := s.format
+		if format == "" {
+			format = storeFormatFromPath(path)
+		}
+		if format == StoreKV || format == StoreSQLite {
+			return snapshot, fmt.Errorf("%s snapshots cannot be %s compressed", format, compression)
+		}
+		ext += compressionExts[compression]
+	}
+
+	data, err := s.fileReader.Open(path)
+	if err != nil {
+		return snapshot, openError(err)
+	}
+	defer data.Close()
+
+	dir := SnapshotDir(path)
+	if err := s.fileReader.MkdirAll(dir); err != nil {
+		return snapshot, err
+	}
+	file, err := s.fileReader.CreateTemp(dir, ".snapshot.tmp-*", path)
+	if err != nil {
+		return snapshot, err
+	}
+	defer func() {
+		if err != nil {
+			file.Close()
+			s.fileReader.Remove(file.Name())
+		}
+	}()
+
+	at = at.UTC()
+	name := filepath.Join(dir, at.Format(snapshotTimeLayout)+ext)
+	w, err := compress(file, name)
+	if err != nil {
+		return snapshot, err
+	}
+	if _, err = io.Copy(w, data); err != nil {
+		return snapshot, err
+	}
+	if err = w.Close(); err != nil {
+		return snapshot, err
+	}
+	if err = file.Sync(); err != nil {
+		return snapshot, err
+	}
+	if err = file.Close(); err != nil {
+		return snapshot, err
+	}
+
+	if err = s.fileReader.Rename(file.Name(), name); err != nil {
+		return snapshot, err
+	}
+	snapshot = Snapshot{Path: name, Time: at}
+
+	if opt.Keep <= 0 {
+		return snapshot, nil
+	}
+	list, err := s.snapshots(ctx, path)
+	if err != nil {
+		return snapshot, err
+	}
+	for len(list) > opt.Keep {
+		if err := s.fileReader.Remove(list[0].Path); err != nil {
+			return snapshot, err
+		}
+		list = list[1:]
+	}
+	return snapshot, nil
+}
+
+// snapshots lists the snapshots of the data file at path, oldest first.
+// Files in SnapshotDir not named after a time are ignored.
+func (s *storage) snapshots(ctx context.Context, path string) (list []Snapshot, err error) {
+	if path == "" {
+		path = "data.csv"
+	}
+
+	dir := SnapshotDir(path)
+	entries, err := s.fileReader.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []Snapshot{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	list = []Snapshot{}
+	for _, v := range entries {
+		name := v.Name()
+		if v.IsDir() || len(name) < len(snapshotTimeLayout) {
+			continue
+		}
+		at, err := time.Parse(snapshotTimeLayout, name[:len(snapshotTimeLayout)])
+		if err != nil {
+			continue
+		}
+		list = append(list, Snapshot{Path: filepath.Join(dir, name), Time: at})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Time.Before(list[j].Time)
+	})
+	return list, nil
+}
+
+// diff compares the users stored in the data files from and to.
+func (s *storage) diff(ctx context.Context, from, to string) (diff Diff, err error) {
+	before, err := s.readAll(ctx, from)
+	if err != nil {
+		return diff, fmt.Errorf("%s: %w", from, err)
+	}
+	after, err := s.readAll(ctx, to)
+	if err != nil {
+		return diff, fmt.Errorf("%s: %w", to, err)
+	}
+	return diffUserData(before, after), nil
+}
+
+// FindSnapshot picks a snapshot of list, sorted oldest first, by ref. A
+// negative number counts back from the latest snapshot, -1 being the latest.
+// Anything else must be a unique prefix of a snapshot name, such as
+// "20240131" or "20240131T0930".
+func FindSnapshot(list []Snapshot, ref string) (Snapshot, error) {
+	if n, err := strconv.Atoi(ref); err == nil && n < 0 {
+		if -n > len(list) {
+			return Snapshot{}, fmt.Errorf("no snapshot %d, there are %d", n, len(list))
+		}
+		return list[len(list)+n], nil
+	}
+
+	var found []Snapshot
+	for _, v := range list {
+		if ref != "" && strings.HasPrefix(v.Name(), ref) {
+			found = append(found, v)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Snapshot{}, fmt.Errorf("no snapshot matches %q", ref)
+	case 1:
+		return found[0], nil
+	}
+	return Snapshot{}, fmt.Errorf("%d snapshots match %q", len(found), ref)
+}
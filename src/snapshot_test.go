@@ -0,0 +1,143 @@
+package src
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_storage_snapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.csv")
+	s := newStorage()
+	ctx := context.Background()
+	start := time.Date(2024, 1, 31, 9, 30, 0, 0, time.UTC)
+
+	if _, err := s.snapshot(ctx, path, start, SnapshotOptions{Keep: 2}); !errors.Is(err, ErrMissingFile) {
+		t.Fatalf("storage.snapshot() error = %v, want %v", err, ErrMissingFile)
+	}
+	if list, err := s.snapshots(ctx, path); err != nil || len(list) != 0 {
+		t.Fatalf("storage.snapshots() = %v, %v, want none", list, err)
+	}
+
+	versions := [][]UserData{
+		{{ID: "1", Balance: "$1.00"}},
+		{{ID: "1", Balance: "$1.50"}, {ID: "2"}},
+		{{ID: "2", ActiveStatus: true}},
+	}
+	var taken []Snapshot
+	for i, data := range versions {
+		if err := s.storeAndReplace(ctx, data, path); err != nil {
+			t.Fatalf("storage.storeAndReplace() error = %v", err)
+		}
+		snapshot, err := s.snapshot(ctx, path, start.Add(time.Duration(i)*time.Minute), SnapshotOptions{Keep: 2})
+		if err != nil {
+			t.Fatalf("storage.snapshot() error = %v", err)
+		}
+		taken = append(taken, snapshot)
+	}
+	if want := filepath.Join(SnapshotDir(path), "20240131T093000.000000000Z.csv"); taken[0].Path != want {
+		t.Errorf("storage.snapshot() path = %s, want %s", taken[0].Path, want)
+	}
+
+	// Only the two latest snapshots are kept, unrelated files are ignored.
+	if err := os.WriteFile(filepath.Join(SnapshotDir(path), "notes.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	list, err := s.snapshots(ctx, path)
+	if err != nil {
+		t.Fatalf("storage.snapshots() error = %v", err)
+	}
+	if !reflect.DeepEqual(list, taken[1:]) {
+		t.Errorf("storage.snapshots() = %v, want %v", list, taken[1:])
+	}
+
+	diff, err := s.diff(ctx, list[0].Path, list[1].Path)
+	if err != nil {
+		t.Fatalf("storage.diff() error = %v", err)
+	}
+	if len(diff.Added) != 0 || len(diff.Removed) != 1 || len(diff.Modified) != 1 {
+		t.Errorf("storage.diff() = %+v", diff)
+	}
+
+	if _, err := s.diff(ctx, taken[0].Path, list[1].Path); err == nil {
+		t.Error("storage.diff() of a removed snapshot succeeded")
+	}
+}
+
+func Test_snapshotExt(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "dir/data.csv", want: ".csv"},
+		{path: "data.csv.gz", want: ".csv.gz"},
+		{path: ".data.json", want: ".json"},
+		{path: "data", want: ""},
+	}
+	for _, tt := range tests {
+		if got := snapshotExt(tt.path); got != tt.want {
+			t.Errorf("snapshotExt(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestFindSnapshot(t *testing.T) {
+	list := []Snapshot{
+		{Path: "s/20240130T120000.000000000Z.csv"},
+		{Path: "s/20240131T093000.000000000Z.csv"},
+		{Path: "s/20240131T100000.000000000Z.csv"},
+	}
+
+	tests := []struct {
+		name    string
+		ref     string
+		want    Snapshot
+		wantErr bool
+	}{
+		{
+			name: "test1_latest",
+			ref:  "-1",
+			want: list[2],
+		},
+		{
+			name: "test2_from_latest",
+			ref:  "-3",
+			want: list[0],
+		},
+		{
+			name:    "test3_out_of_range",
+			ref:     "-4",
+			wantErr: true,
+		},
+		{
+			name: "test4_prefix",
+			ref:  "20240131T09",
+			want: list[1],
+		},
+		{
+			name:    "test5_ambiguous",
+			ref:     "20240131",
+			wantErr: true,
+		},
+		{
+			name:    "test6_no_match",
+			ref:     "2023",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindSnapshot(list, tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FindSnapshot() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("FindSnapshot() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package src
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SourcesEnv is the environment variable read by LoadSources, holding source
+// specs (see ParseSource) separated by ";;" or newlines. Commas are left to
+// the specs, which may have them in a URL query or a header value.
+const SourcesEnv = "CCLI_SOURCES"
+
+// splitSourcesEnv splits the value of SourcesEnv into specs.
+func splitSourcesEnv(env string) []string {
+	return strings.Split(strings.ReplaceAll(env, ";;", "\n"), "\n")
+}
+
+// Source is a single upstream endpoint serving []UserData as JSON.
+type Source struct {
+	URL     string
+	Method  string
+	Headers map[string]string
+	// Timeout bounds a single request to this source on top of the client
+	// timeout. Zero means no extra limit.
+	Timeout time.Duration
+	// Priority orders the sources, lower values are tried first.
+	Priority int
+}
+
+type sourceJSON struct {
+	URL      string            `json:"url"`
+	Method   string            `json:"method,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Timeout  string            `json:"timeout,omitempty"`
+	Priority int               `json:"priority,omitempty"`
+}
+
+func (s Source) MarshalJSON() ([]byte, error) {
+	v := sourceJSON{
+		URL:      s.URL,
+		Method:   s.Method,
+		Headers:  s.Headers,
+		Priority: s.Priority,
+	}
+	if s.Timeout > 0 {
+		v.Timeout = s.Timeout.String()
+	}
+	return json.Marshal(v)
+}
+
+func (s *Source) UnmarshalJSON(b []byte) error {
+	var v sourceJSON
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	*s = Source{
+		URL:      v.URL,
+		Method:   v.Method,
+		Headers:  v.Headers,
+		Priority: v.Priority,
+	}
+	if v.Timeout != "" {
+		timeout, err := time.ParseDuration(v.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout for source %q: %w", v.URL, err)
+		}
+		s.Timeout = timeout
+	}
+	return nil
+}
+
+// DefaultSources returns the built in mocky.io sources.
+func DefaultSources() []Source {
+	return []Source{
+		{URL: APILink1},
+		{URL: APILink2},
+	}
+}
+
+// ParseSource parses a source spec in the form
+//
+//	URL[;method=POST][;timeout=5s][;priority=1][;header=Key: Value]...
+//
+// as accepted by the -source flag and the CCLI_SOURCES variable.
+func ParseSource(spec string) (Source, error) {
+	parts := strings.Split(spec, ";")
+	s := Source{
+		URL: strings.TrimSpace(parts[0]),
+	}
+	if s.URL == "" {
+		return s, errors.New("missing source url")
+	}
+
+	for _, v := range parts[1:] {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 {
+			return s, fmt.Errorf("invalid source option %q", v)
+		}
+		key, value := strings.ToLower(strings.TrimSpace(kv[0])), strings.TrimSpace(kv[1])
+
+		switch key {
+		case "method":
+			s.Method = strings.ToUpper(value)
+		case "timeout":
+			timeout, err := time.ParseDuration(value)
+			if err != nil {
+				return s, fmt.Errorf("invalid source timeout %q", value)
+			}
+			s.Timeout = timeout
+		case "priority":
+			priority, err := strconv.Atoi(value)
+			if err != nil {
+				return s, fmt.Errorf("invalid source priority %q", value)
+			}
+			s.Priority = priority
+		case "header":
+			hv := strings.SplitN(value, ":", 2)
+			if len(hv) != 2 || strings.TrimSpace(hv[0]) == "" {
+				return s, fmt.Errorf("invalid source header %q", value)
+			}
+			if s.Headers == nil {
+				s.Headers = make(map[string]string)
+			}
+			s.Headers[strings.TrimSpace(hv[0])] = strings.TrimSpace(hv[1])
+		default:
+			return s, fmt.Errorf("unknown source option %q", key)
+		}
+	}
+
+	return s, nil
+}
+
+// LoadSourcesFile reads sources from a JSON config file shaped as
+// {"sources": [{"url": "...", "method": "GET", "headers": {}, "timeout": "5s", "priority": 1}]}.
+func LoadSourcesFile(path string) ([]Source, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg struct {
+		Sources []Source `json:"sources"`
+	}
+	err = json.Unmarshal(content, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Sources) == 0 {
+		return nil, fmt.Errorf("no sources found in %s", path)
+	}
+	return cfg.Sources, nil
+}
+
+// LoadSources resolves the sources to fetch from. The first non empty origin
+// wins, the explicit ones first: specs (repeated -source flags), then the
+// config file at configPath, then the CCLI_SOURCES variable, then
+// DefaultSources.
+func LoadSources(configPath string, specs []string) ([]Source, error) {
+	if len(specs) > 0 {
+		return parseSources(specs)
+	}
+
+	if configPath != "" {
+		return LoadSourcesFile(configPath)
+	}
+
+	if env := strings.TrimSpace(os.Getenv(SourcesEnv)); env != "" {
+		return parseSources(splitSourcesEnv(env))
+	}
+
+	return DefaultSources(), nil
+}
+
+// parseSources parses every non blank spec.
+func parseSources(specs []string) ([]Source, error) {
+	sources := make([]Source, 0, len(specs))
+	for _, v := range specs {
+		if strings.TrimSpace(v) == "" {
+			continue
+		}
+		s, err := ParseSource(v)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, s)
+	}
+	return sources, nil
+}
+
+// orderSources drops sources without URL, fills the default method and sorts
+// by priority keeping the given order for equal priorities.
+func orderSources(sources []Source) []Source {
+	ordered := make([]Source, 0, len(sources))
+	for _, v := range sources {
+		v.URL = strings.TrimSpace(v.URL)
+		if v.URL == "" {
+			continue
+		}
+		if v.Method == "" {
+			v.Method = http.MethodGet
+		}
+		ordered = append(ordered, v)
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Priority < ordered[j].Priority
+	})
+	return ordered
+}
@@ -0,0 +1,339 @@
+package src
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func TestParseSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    Source
+		wantErr bool
+	}{
+		{
+			name:    "test1_missing_url",
+			spec:    " ;method=GET",
+			wantErr: true,
+		},
+		{
+			name: "test2_url_only",
+			spec: " http://localhost:8080 ",
+			want: Source{
+				URL: "http://localhost:8080",
+			},
+		},
+		{
+			name: "test3_all_options",
+			spec: "http://localhost:8080;method=post;timeout=2s;priority=3;header=Authorization: Bearer x;header=X-Env:dev",
+			want: Source{
+				URL:      "http://localhost:8080",
+				Method:   http.MethodPost,
+				Timeout:  2 * time.Second,
+				Priority: 3,
+				Headers: map[string]string{
+					"Authorization": "Bearer x",
+					"X-Env":         "dev",
+				},
+			},
+		},
+		{
+			name:    "test4_bad_timeout",
+			spec:    "http://localhost:8080;timeout=soon",
+			wantErr: true,
+		},
+		{
+			name:    "test5_bad_priority",
+			spec:    "http://localhost:8080;priority=high",
+			wantErr: true,
+		},
+		{
+			name:    "test6_bad_header",
+			spec:    "http://localhost:8080;header=nocolon",
+			wantErr: true,
+		},
+		{
+			name:    "test7_unknown_option",
+			spec:    "http://localhost:8080;retries=3",
+			wantErr: true,
+		},
+		{
+			name:    "test8_option_without_value",
+			spec:    "http://localhost:8080;method",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSource(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseSource() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSource() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSourcesFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    []Source
+		wantErr bool
+	}{
+		{
+			name:    "test1_missing_file",
+			path:    filepath.Join(dir, "missing.json"),
+			wantErr: true,
+		},
+		{
+			name:    "test2_bad_json",
+			path:    write("bad.json", "{"),
+			wantErr: true,
+		},
+		{
+			name:    "test3_no_sources",
+			path:    write("empty.json", `{"sources": []}`),
+			wantErr: true,
+		},
+		{
+			name:    "test4_bad_timeout",
+			path:    write("timeout.json", `{"sources": [{"url": "http://a", "timeout": "x"}]}`),
+			wantErr: true,
+		},
+		{
+			name: "test5_success",
+			path: write("ok.json", `{"sources": [
+				{"url": "http://a", "method": "POST", "headers": {"X-Key": "1"}, "timeout": "1s", "priority": 2},
+				{"url": "http://b"}
+			]}`),
+			want: []Source{
+				{
+					URL:      "http://a",
+					Method:   http.MethodPost,
+					Headers:  map[string]string{"X-Key": "1"},
+					Timeout:  time.Second,
+					Priority: 2,
+				},
+				{
+					URL: "http://b",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadSourcesFile(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadSourcesFile() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadSourcesFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sources.json")
+	if err := ioutil.WriteFile(path, []byte(`{"sources": [{"url": "http://file"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	type args struct {
+		configPath string
+		specs      []string
+	}
+	tests := []struct {
+		name    string
+		env     string
+		args    args
+		want    []Source
+		wantErr bool
+	}{
+		{
+			name: "test1_default",
+			want: DefaultSources(),
+		},
+		{
+			name: "test2_config_file",
+			args: args{
+				configPath: path,
+			},
+			want: []Source{{URL: "http://file"}},
+		},
+		{
+			name: "test3_env",
+			env:  "http://env1?ids=1,2 ;; http://env2;priority=1;header=Accept: a, b\nhttp://env3\n",
+			want: []Source{
+				{URL: "http://env1?ids=1,2"},
+				{URL: "http://env2", Priority: 1, Headers: map[string]string{"Accept": "a, b"}},
+				{URL: "http://env3"},
+			},
+		},
+		{
+			name: "test4_file_over_env",
+			env:  "http://env1",
+			args: args{
+				configPath: path,
+			},
+			want: []Source{{URL: "http://file"}},
+		},
+		{
+			name: "test5_flags_over_file_and_env",
+			env:  "http://env1",
+			args: args{
+				configPath: path,
+				specs:      []string{"http://flag"},
+			},
+			want: []Source{{URL: "http://flag"}},
+		},
+		{
+			name:    "test6_bad_env_spec",
+			env:     "http://env1;priority=x",
+			wantErr: true,
+		},
+		{
+			name: "test7_bad_spec",
+			args: args{
+				specs: []string{"http://flag;priority=x"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(SourcesEnv, tt.env)
+			got, err := LoadSources(tt.args.configPath, tt.args.specs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadSources() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadSources() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_orderSources(t *testing.T) {
+	got := orderSources([]Source{
+		{URL: "http://c", Priority: 2},
+		{URL: "  "},
+		{URL: " http://a ", Method: http.MethodPost},
+		{URL: "http://b"},
+		{URL: "http://d", Priority: -1},
+	})
+	want := []Source{
+		{URL: "http://d", Method: http.MethodGet, Priority: -1},
+		{URL: "http://a", Method: http.MethodPost},
+		{URL: "http://b", Method: http.MethodGet},
+		{URL: "http://c", Method: http.MethodGet, Priority: 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("orderSources() = %v, want %v", got, want)
+	}
+}
+
+func Test_apiFetcher_openSource(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", "http://localhost:8200", func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("X-Key") != "secret" {
+			return httpmock.NewStringResponse(http.StatusUnauthorized, ""), nil
+		}
+		return httpmock.NewStringResponse(http.StatusOK, "[]"), nil
+	})
+	httpmock.RegisterResponder("GET", "http://localhost:8201", func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})
+
+	tests := []struct {
+		name     string
+		source   Source
+		wantResp httpResponseGeneral
+		wantErr  bool
+	}{
+		{
+			name: "test1_success_with_headers",
+			source: Source{
+				URL:     "http://localhost:8200",
+				Method:  http.MethodPost,
+				Headers: map[string]string{"X-Key": "secret"},
+			},
+			wantResp: httpResponseGeneral{
+				content: []byte("[]"),
+				code:    http.StatusOK,
+			},
+		},
+		{
+			name: "test2_missing_headers",
+			source: Source{
+				URL:    "http://localhost:8200",
+				Method: http.MethodPost,
+			},
+			wantResp: httpResponseGeneral{
+				code: http.StatusUnauthorized,
+			},
+			wantErr: true,
+		},
+		{
+			name: "test3_timeout",
+			source: Source{
+				URL:     "http://localhost:8201",
+				Method:  http.MethodGet,
+				Timeout: 10 * time.Millisecond,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &apiFetcher{
+				httpClient: &http.Client{},
+			}
+			var gotResp httpResponseGeneral
+			body, err := f.openSource(context.Background(), tt.source)
+			if body != nil {
+				gotResp.code = http.StatusOK
+				gotResp.content, err = ioutil.ReadAll(body)
+				body.Close()
+			}
+			var fetchErr *FetchError
+			if errors.As(err, &fetchErr) {
+				gotResp.code = fetchErr.StatusCode
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("apiFetcher.openSource() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotResp, tt.wantResp) {
+				t.Errorf("apiFetcher.openSource() = %v, want %v", gotResp, tt.wantResp)
+			}
+		})
+	}
+}
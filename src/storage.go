@@ -1,117 +1,320 @@
 package src
 
 import (
-	"bufio"
 	"context"
-	"encoding/json"
 	"errors"
-	"io"
-	"strconv"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
 //go:generate mockgen -destination=storage_mock.go -package=src -source=storage.go
 type (
 	storageIface interface {
-		storeAndReplaceUserDataToCSV(ctx context.Context, data []UserData, path string) error
-		searchFromCSV(ctx context.Context, tags []string, path string) (data []UserData, err error)
+		storeAndReplace(ctx context.Context, data []UserData, path string) error
+		storeStream(ctx context.Context, users userSeq, path string) error
+		search(ctx context.Context, tags []string, path string) (data []UserData, err error)
+		searchWithFilter(ctx context.Context, filter Filter, path string) (data []UserData, err error)
+		searchEach(ctx context.Context, filter Filter, path string, fn func(user UserData) error) (err error)
+		lookup(ctx context.Context, id string, path string) (user UserData, found bool, err error)
+		inspect(ctx context.Context, path string) (report Report, err error)
+		migrate(ctx context.Context, path string) (migrated bool, err error)
+		upsert(ctx context.Context, data []UserData, path string, policy TombstonePolicy) (result UpsertResult, err error)
+		snapshot(ctx context.Context, path string, at time.Time, opt SnapshotOptions) (snapshot Snapshot, err error)
+		snapshots(ctx context.Context, path string) (list []Snapshot, err error)
+		diff(ctx context.Context, from, to string) (diff Diff, err error)
 	}
 
 	storage struct {
 		fileReader fReaderIface
 		csvHandler csvHandlerIface
+		// format is one of StoreFormats, empty picks it from the path extension.
+		format string
 	}
 )
 
-var (
-	ErrMissingFile = errors.New("missing file")
-)
+// Storage reads and writes the data files of a Client, see
+// ClientOptions.Storage. Its methods are unexported, a Storage is obtained
+// from NewStorage or, in this package's tests, a MockstorageIface.
+type Storage interface {
+	storageIface
+}
+
+// NewStorage returns the Storage of the given format, one of StoreFormats.
+// An empty format picks it from the extension of each path, CSV by default.
+// A Storage holds no state and can be shared by several Clients.
+func NewStorage(format string) (Storage, error) {
+	format, err := ParseStoreFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	return newStorageWithFormat(format), nil
+}
 
 func newStorage() storageIface {
+	return newStorageWithFormat("")
+}
+
+func newStorageWithFormat(format string) storageIface {
 	return &storage{
 		fileReader: &fileHandler{},
 		csvHandler: &csvHandler{},
+		format:     format,
 	}
 }
 
-func (s *storage) storeAndReplaceUserDataToCSV(ctx context.Context, data []UserData, path string) error {
+func (s *storage) storeAndReplace(ctx context.Context, data []UserData, path string) error {
+	return s.storeStream(ctx, sliceSeq(data), path)
+}
+
+// storeStream writes users to a temporary file next to path as they come,
+// syncs it and renames it over path, so readers either see the previous file
+// or the complete new one. The previous file is left untouched on failure,
+// including an error from users.
+func (s *storage) storeStream(ctx context.Context, users userSeq, path string) (err error) {
 	if path == "" {
 		path = "data.csv"
 	}
-	// file, err := os.Create(path)
-	file, err := s.fileReader.Create(path)
+
+	backend := s.backend(path)
+	file, err := s.fileReader.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*", path)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-
-	writer := s.csvHandler.NewWriter(file)
-	defer writer.Flush()
-	// writer := csv.NewWriter(file)
-	for _, v := range data {
-		tagBytes, err := json.Marshal(&v.Tags)
+	defer func() {
 		if err != nil {
-			return err
+			file.Close()
+			s.fileReader.Remove(file.Name())
 		}
-		err = writer.Write([]string{v.ID, strconv.FormatBool(v.ActiveStatus), v.Balance, string(tagBytes)})
-		if err != nil {
+	}()
+
+	if err = backend.write(ctx, file, path, seqContext(ctx, users)); err != nil {
+		return err
+	}
+	if err = file.Sync(); err != nil {
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	// A write cancelled after its last user still keeps the previous file.
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+
+	return s.fileReader.Rename(file.Name(), path)
+}
+
+// seqContext stops users once ctx is done.
+func seqContext(ctx context.Context, users userSeq) userSeq {
+	return func(yield func(user UserData) error) error {
+		return users(func(user UserData) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return yield(user)
+		})
+	}
+}
+
+// scanContext stops a scan once ctx is done, so a cancelled command does not
+// read the rest of a large file.
+func scanContext(ctx context.Context, fn scanFunc) scanFunc {
+	return func(row int, user UserData, rowErr error) error {
+		if err := ctx.Err(); err != nil {
 			return err
 		}
+		return fn(row, user, rowErr)
 	}
+}
 
-	return nil
+func (s *storage) search(ctx context.Context, tags []string, path string) (data []UserData, err error) {
+	return s.searchWithFilter(ctx, Filter{Query: AllTagsQuery(tags)}, path)
 }
 
-func (s *storage) searchFromCSV(ctx context.Context, tags []string, path string) (data []UserData, err error) {
+// searchWithFilter returns the rows passing every condition of filter.
+func (s *storage) searchWithFilter(ctx context.Context, filter Filter, path string) (data []UserData, err error) {
+	err = s.searchEach(ctx, filter, path, func(user UserData) error {
+		data = append(data, user)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// searchEach calls fn with every row passing every condition of filter, as
+// soon as it is read. An error from fn stops the search and is returned,
+// except ErrStopSearch.
+func (s *storage) searchEach(ctx context.Context, filter Filter, path string, fn func(user UserData) error) (err error) {
 	if path == "" {
 		path = "data.csv"
 	}
 
-	file, err := s.fileReader.Open(path)
-	if err != nil {
-		return nil, ErrMissingFile
-	}
-	defer file.Close()
+	backend := s.backend(path)
+	match := scanContext(ctx, func(row int, user UserData, rowErr error) error {
+		if rowErr != nil {
+			return rowErr
+		}
 
-	csvReader := s.csvHandler.NewReader(bufio.NewReader(file))
-	for {
-		res, err := csvReader.Read()
-		if err == io.EOF {
-			break
+		tagMap := make(map[string]struct{})
+		for _, v := range user.Tags {
+			tagMap[v] = struct{}{}
 		}
-		if err != nil {
-			return nil, err
+
+		if filter.Match(user, tagMap) {
+			return fn(user)
 		}
-		if len(res) < 4 {
-			return nil, errors.New("bad csv row format")
+		return nil
+	})
+
+	// Backends with a tag index only visit users having every required tag,
+	// the filter still checks the rest of the query.
+	index, ok := backend.(tagIndexIface)
+	if tags := requiredTags(filter.Query); ok && len(tags) > 0 {
+		_, err = index.scanTags(ctx, path, tags, match)
+	} else {
+		_, err = backend.scan(ctx, path, match)
+	}
+	if errors.Is(err, ErrStopSearch) {
+		return nil
+	}
+	return err
+}
+
+// lookup returns the first user stored with id, found is false when there is
+// none. Backends keyed by ID read it directly, the others are scanned up to
+// the first match.
+func (s *storage) lookup(ctx context.Context, id string, path string) (user UserData, found bool, err error) {
+	if path == "" {
+		path = "data.csv"
+	}
+
+	backend := s.backend(path)
+	if index, ok := backend.(idLookupIface); ok {
+		return index.lookupID(ctx, path, id)
+	}
+	_, err = backend.scan(ctx, path, scanContext(ctx, func(row int, v UserData, rowErr error) error {
+		if rowErr != nil {
+			return rowErr
+		}
+		if v.ID != id {
+			return nil
 		}
+		user, found = v, true
+		return ErrStopSearch
+	}))
+	if errors.Is(err, ErrStopSearch) {
+		err = nil
+	}
+	return user, found, err
+}
 
-		var rowTags []string
-		err = json.Unmarshal([]byte(res[3]), &rowTags)
+// inspect reads every row of the file, counting the usable ones and
+// collecting a RowProblem for each row that search would reject or that
+// repeats an ID. Only failures to read the file itself are returned as error.
+func (s *storage) inspect(ctx context.Context, path string) (report Report, err error) {
+	if path == "" {
+		path = "data.csv"
+	}
+
+	backend := s.backend(path)
+	report.Tags = make(map[string]int)
+	seen := make(map[string]int)
+	report.Version, err = backend.scan(ctx, path, scanContext(ctx, func(row int, user UserData, rowErr error) error {
+		err := rowErr
+		if err == nil && strings.TrimSpace(user.ID) == "" {
+			err = ErrEmptyID
+		}
 		if err != nil {
-			return nil, err
+			report.Problems = append(report.Problems, RowProblem{Row: row, Err: err})
+			return nil
 		}
-
-		tagMap := make(map[string]struct{})
-		for _, v := range rowTags {
-			tagMap[v] = struct{}{}
+		if prev, ok := seen[user.ID]; ok {
+			report.Problems = append(report.Problems, RowProblem{Row: row, Err: fmt.Errorf("%w %q, first seen on row %d", ErrDuplicateID, user.ID, prev)})
+			return nil
 		}
+		seen[user.ID] = row
 
-		shouldAppend := true
-		for _, v := range tags {
-			if _, ok := tagMap[v]; !ok {
-				shouldAppend = false
-				break
-			}
+		report.Rows++
+		if user.ActiveStatus {
+			report.Active++
+		} else {
+			report.Inactive++
 		}
-		if !shouldAppend {
-			continue
+		for _, v := range user.Tags {
+			report.Tags[v]++
 		}
+		return nil
+	}))
+	if err != nil {
+		return report, err
+	}
 
-		data = append(data, UserData{
-			ID:      res[0],
-			Balance: res[2],
-		})
+	return report, nil
+}
+
+// migrate rewrites a file of an older schema version in the current one.
+// Files already in the current version are left untouched and reported with
+// migrated false.
+func (s *storage) migrate(ctx context.Context, path string) (migrated bool, err error) {
+	if path == "" {
+		path = "data.csv"
+	}
+
+	backend := s.backend(path)
+	var data []UserData
+	version, err := backend.scan(ctx, path, scanContext(ctx, func(row int, user UserData, rowErr error) error {
+		if rowErr != nil {
+			return rowErr
+		}
+		data = append(data, user)
+		return nil
+	}))
+	if err != nil {
+		return false, err
+	}
+	if version == SchemaVersion {
+		return false, nil
+	}
+
+	if err := s.storeAndReplace(ctx, data, path); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// upsert merges data into the users stored at path, see upsertUserData, and
+// replaces the file with the result. A missing file is created.
+func (s *storage) upsert(ctx context.Context, data []UserData, path string, policy TombstonePolicy) (result UpsertResult, err error) {
+	if path == "" {
+		path = "data.csv"
 	}
 
-	return
+	stored, err := s.readAll(ctx, path)
+	if err != nil && !errors.Is(err, ErrMissingFile) {
+		return result, err
+	}
+
+	merged, result := upsertUserData(stored, data, policy)
+	if err := s.storeAndReplace(ctx, merged, path); err != nil {
+		return UpsertResult{}, err
+	}
+	return result, nil
+}
+
+// readAll returns every user stored at path, the first malformed row is
+// returned as a *ParseError.
+func (s *storage) readAll(ctx context.Context, path string) (data []UserData, err error) {
+	_, err = s.backend(path).scan(ctx, path, scanContext(ctx, func(row int, user UserData, rowErr error) error {
+		if rowErr != nil {
+			return rowErr
+		}
+		data = append(data, user)
+		return nil
+	}))
+	return data, err
 }
@@ -7,6 +7,7 @@ package src
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -34,31 +35,381 @@ func (m *MockstorageIface) EXPECT() *MockstorageIfaceMockRecorder {
 	return m.recorder
 }
 
-// searchFromCSV mocks base method.
-func (m *MockstorageIface) searchFromCSV(ctx context.Context, tags []string, path string) ([]UserData, error) {
+// diff mocks base method.
+func (m *MockstorageIface) diff(ctx context.Context, from, to string) (Diff, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "searchFromCSV", ctx, tags, path)
+	ret := m.ctrl.Call(m, "diff", ctx, from, to)
+	ret0, _ := ret[0].(Diff)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// diff indicates an expected call of diff.
+func (mr *MockstorageIfaceMockRecorder) diff(ctx, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "diff", reflect.TypeOf((*MockstorageIface)(nil).diff), ctx, from, to)
+}
+
+// inspect mocks base method.
+func (m *MockstorageIface) inspect(ctx context.Context, path string) (Report, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "inspect", ctx, path)
+	ret0, _ := ret[0].(Report)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// inspect indicates an expected call of inspect.
+func (mr *MockstorageIfaceMockRecorder) inspect(ctx, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "inspect", reflect.TypeOf((*MockstorageIface)(nil).inspect), ctx, path)
+}
+
+// lookup mocks base method.
+func (m *MockstorageIface) lookup(ctx context.Context, id, path string) (UserData, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "lookup", ctx, id, path)
+	ret0, _ := ret[0].(UserData)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// lookup indicates an expected call of lookup.
+func (mr *MockstorageIfaceMockRecorder) lookup(ctx, id, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "lookup", reflect.TypeOf((*MockstorageIface)(nil).lookup), ctx, id, path)
+}
+
+// migrate mocks base method.
+func (m *MockstorageIface) migrate(ctx context.Context, path string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "migrate", ctx, path)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// migrate indicates an expected call of migrate.
+func (mr *MockstorageIfaceMockRecorder) migrate(ctx, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "migrate", reflect.TypeOf((*MockstorageIface)(nil).migrate), ctx, path)
+}
+
+// search mocks base method.
+func (m *MockstorageIface) search(ctx context.Context, tags []string, path string) ([]UserData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "search", ctx, tags, path)
+	ret0, _ := ret[0].([]UserData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// search indicates an expected call of search.
+func (mr *MockstorageIfaceMockRecorder) search(ctx, tags, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "search", reflect.TypeOf((*MockstorageIface)(nil).search), ctx, tags, path)
+}
+
+// searchEach mocks base method.
+func (m *MockstorageIface) searchEach(ctx context.Context, filter Filter, path string, fn func(UserData) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "searchEach", ctx, filter, path, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// searchEach indicates an expected call of searchEach.
+func (mr *MockstorageIfaceMockRecorder) searchEach(ctx, filter, path, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "searchEach", reflect.TypeOf((*MockstorageIface)(nil).searchEach), ctx, filter, path, fn)
+}
+
+// searchWithFilter mocks base method.
+func (m *MockstorageIface) searchWithFilter(ctx context.Context, filter Filter, path string) ([]UserData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "searchWithFilter", ctx, filter, path)
 	ret0, _ := ret[0].([]UserData)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// searchFromCSV indicates an expected call of searchFromCSV.
-func (mr *MockstorageIfaceMockRecorder) searchFromCSV(ctx, tags, path interface{}) *gomock.Call {
+// searchWithFilter indicates an expected call of searchWithFilter.
+func (mr *MockstorageIfaceMockRecorder) searchWithFilter(ctx, filter, path interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "searchFromCSV", reflect.TypeOf((*MockstorageIface)(nil).searchFromCSV), ctx, tags, path)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "searchWithFilter", reflect.TypeOf((*MockstorageIface)(nil).searchWithFilter), ctx, filter, path)
 }
 
-// storeAndReplaceUserDataToCSV mocks base method.
-func (m *MockstorageIface) storeAndReplaceUserDataToCSV(ctx context.Context, data []UserData, path string) error {
+// snapshot mocks base method.
+func (m *MockstorageIface) snapshot(ctx context.Context, path string, at time.Time, opt SnapshotOptions) (Snapshot, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "storeAndReplaceUserDataToCSV", ctx, data, path)
+	ret := m.ctrl.Call(m, "snapshot", ctx, path, at, opt)
+	ret0, _ := ret[0].(Snapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// snapshot indicates an expected call of snapshot.
+func (mr *MockstorageIfaceMockRecorder) snapshot(ctx, path, at, opt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "snapshot", reflect.TypeOf((*MockstorageIface)(nil).snapshot), ctx, path, at, opt)
+}
+
+// snapshots mocks base method.
+func (m *MockstorageIface) snapshots(ctx context.Context, path string) ([]Snapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "snapshots", ctx, path)
+	ret0, _ := ret[0].([]Snapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// snapshots indicates an expected call of snapshots.
+func (mr *MockstorageIfaceMockRecorder) snapshots(ctx, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "snapshots", reflect.TypeOf((*MockstorageIface)(nil).snapshots), ctx, path)
+}
+
+// storeAndReplace mocks base method.
+func (m *MockstorageIface) storeAndReplace(ctx context.Context, data []UserData, path string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "storeAndReplace", ctx, data, path)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// storeAndReplace indicates an expected call of storeAndReplace.
+func (mr *MockstorageIfaceMockRecorder) storeAndReplace(ctx, data, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "storeAndReplace", reflect.TypeOf((*MockstorageIface)(nil).storeAndReplace), ctx, data, path)
+}
+
+// storeStream mocks base method.
+func (m *MockstorageIface) storeStream(ctx context.Context, users userSeq, path string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "storeStream", ctx, users, path)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// storeAndReplaceUserDataToCSV indicates an expected call of storeAndReplaceUserDataToCSV.
-func (mr *MockstorageIfaceMockRecorder) storeAndReplaceUserDataToCSV(ctx, data, path interface{}) *gomock.Call {
+// storeStream indicates an expected call of storeStream.
+func (mr *MockstorageIfaceMockRecorder) storeStream(ctx, users, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "storeStream", reflect.TypeOf((*MockstorageIface)(nil).storeStream), ctx, users, path)
+}
+
+// upsert mocks base method.
+func (m *MockstorageIface) upsert(ctx context.Context, data []UserData, path string, policy TombstonePolicy) (UpsertResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "upsert", ctx, data, path, policy)
+	ret0, _ := ret[0].(UpsertResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// upsert indicates an expected call of upsert.
+func (mr *MockstorageIfaceMockRecorder) upsert(ctx, data, path, policy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "upsert", reflect.TypeOf((*MockstorageIface)(nil).upsert), ctx, data, path, policy)
+}
+
+// MockStorage is a mock of Storage interface.
+type MockStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockStorageMockRecorder
+}
+
+// MockStorageMockRecorder is the mock recorder for MockStorage.
+type MockStorageMockRecorder struct {
+	mock *MockStorage
+}
+
+// NewMockStorage creates a new mock instance.
+func NewMockStorage(ctrl *gomock.Controller) *MockStorage {
+	mock := &MockStorage{ctrl: ctrl}
+	mock.recorder = &MockStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
+	return m.recorder
+}
+
+// diff mocks base method.
+func (m *MockStorage) diff(ctx context.Context, from, to string) (Diff, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "diff", ctx, from, to)
+	ret0, _ := ret[0].(Diff)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// diff indicates an expected call of diff.
+func (mr *MockStorageMockRecorder) diff(ctx, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "diff", reflect.TypeOf((*MockStorage)(nil).diff), ctx, from, to)
+}
+
+// inspect mocks base method.
+func (m *MockStorage) inspect(ctx context.Context, path string) (Report, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "inspect", ctx, path)
+	ret0, _ := ret[0].(Report)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// inspect indicates an expected call of inspect.
+func (mr *MockStorageMockRecorder) inspect(ctx, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "inspect", reflect.TypeOf((*MockStorage)(nil).inspect), ctx, path)
+}
+
+// lookup mocks base method.
+func (m *MockStorage) lookup(ctx context.Context, id, path string) (UserData, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "lookup", ctx, id, path)
+	ret0, _ := ret[0].(UserData)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// lookup indicates an expected call of lookup.
+func (mr *MockStorageMockRecorder) lookup(ctx, id, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "lookup", reflect.TypeOf((*MockStorage)(nil).lookup), ctx, id, path)
+}
+
+// migrate mocks base method.
+func (m *MockStorage) migrate(ctx context.Context, path string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "migrate", ctx, path)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// migrate indicates an expected call of migrate.
+func (mr *MockStorageMockRecorder) migrate(ctx, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "migrate", reflect.TypeOf((*MockStorage)(nil).migrate), ctx, path)
+}
+
+// search mocks base method.
+func (m *MockStorage) search(ctx context.Context, tags []string, path string) ([]UserData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "search", ctx, tags, path)
+	ret0, _ := ret[0].([]UserData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// search indicates an expected call of search.
+func (mr *MockStorageMockRecorder) search(ctx, tags, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "search", reflect.TypeOf((*MockStorage)(nil).search), ctx, tags, path)
+}
+
+// searchEach mocks base method.
+func (m *MockStorage) searchEach(ctx context.Context, filter Filter, path string, fn func(UserData) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "searchEach", ctx, filter, path, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// searchEach indicates an expected call of searchEach.
+func (mr *MockStorageMockRecorder) searchEach(ctx, filter, path, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "searchEach", reflect.TypeOf((*MockStorage)(nil).searchEach), ctx, filter, path, fn)
+}
+
+// searchWithFilter mocks base method.
+func (m *MockStorage) searchWithFilter(ctx context.Context, filter Filter, path string) ([]UserData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "searchWithFilter", ctx, filter, path)
+	ret0, _ := ret[0].([]UserData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// searchWithFilter indicates an expected call of searchWithFilter.
+func (mr *MockStorageMockRecorder) searchWithFilter(ctx, filter, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "searchWithFilter", reflect.TypeOf((*MockStorage)(nil).searchWithFilter), ctx, filter, path)
+}
+
+// snapshot mocks base method.
+func (m *MockStorage) snapshot(ctx context.Context, path string, at time.Time, opt SnapshotOptions) (Snapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "snapshot", ctx, path, at, opt)
+	ret0, _ := ret[0].(Snapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// snapshot indicates an expected call of snapshot.
+func (mr *MockStorageMockRecorder) snapshot(ctx, path, at, opt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "snapshot", reflect.TypeOf((*MockStorage)(nil).snapshot), ctx, path, at, opt)
+}
+
+// snapshots mocks base method.
+func (m *MockStorage) snapshots(ctx context.Context, path string) ([]Snapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "snapshots", ctx, path)
+	ret0, _ := ret[0].([]Snapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// snapshots indicates an expected call of snapshots.
+func (mr *MockStorageMockRecorder) snapshots(ctx, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "snapshots", reflect.TypeOf((*MockStorage)(nil).snapshots), ctx, path)
+}
+
+// storeAndReplace mocks base method.
+func (m *MockStorage) storeAndReplace(ctx context.Context, data []UserData, path string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "storeAndReplace", ctx, data, path)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// storeAndReplace indicates an expected call of storeAndReplace.
+func (mr *MockStorageMockRecorder) storeAndReplace(ctx, data, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "storeAndReplace", reflect.TypeOf((*MockStorage)(nil).storeAndReplace), ctx, data, path)
+}
+
+// storeStream mocks base method.
+func (m *MockStorage) storeStream(ctx context.Context, users userSeq, path string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "storeStream", ctx, users, path)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// storeStream indicates an expected call of storeStream.
+func (mr *MockStorageMockRecorder) storeStream(ctx, users, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "storeStream", reflect.TypeOf((*MockStorage)(nil).storeStream), ctx, users, path)
+}
+
+// upsert mocks base method.
+func (m *MockStorage) upsert(ctx context.Context, data []UserData, path string, policy TombstonePolicy) (UpsertResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "upsert", ctx, data, path, policy)
+	ret0, _ := ret[0].(UpsertResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// upsert indicates an expected call of upsert.
+func (mr *MockStorageMockRecorder) upsert(ctx, data, path, policy interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "storeAndReplaceUserDataToCSV", reflect.TypeOf((*MockstorageIface)(nil).storeAndReplaceUserDataToCSV), ctx, data, path)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "upsert", reflect.TypeOf((*MockStorage)(nil).upsert), ctx, data, path, policy)
 }
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"io"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -33,10 +34,19 @@ func Test_newStorage(t *testing.T) {
 	}
 }
 
-func Test_storage_storeAndReplaceUserDataToCSV(t *testing.T) {
+func Test_storage_storeAndReplace(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 
+	dir := t.TempDir()
+	tempFile := func() *os.File {
+		file, err := os.CreateTemp(dir, "dd.csv.tmp-*")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+
 	type fields struct {
 		fileReader fReaderIface
 		csvHandler csvHandlerIface
@@ -68,16 +78,24 @@ func Test_storage_storeAndReplaceUserDataToCSV(t *testing.T) {
 			},
 			fields: fields{
 				fileReader: func() fReaderIface {
+					file := tempFile()
 					mock := NewMockfReaderIface(mockCtrl)
-					mock.EXPECT().Create("dd.csv").Return(&os.File{}, nil).Times(1)
+					index := tempFile()
+					mock.EXPECT().CreateTemp(".", ".dd.csv.tmp-*", "dd.csv").Return(file, nil).Times(1)
+					mock.EXPECT().CreateTemp(".", ".dd.csv.idx.tmp-*", "dd.csv").Return(index, nil).Times(1)
+					mock.EXPECT().Rename(index.Name(), "dd.csv.idx").Return(nil).Times(1)
+					mock.EXPECT().Rename(file.Name(), "dd.csv").Return(nil).Times(1)
 					return mock
 				}(),
 				csvHandler: func() csvHandlerIface {
 					mockWriter := NewMockcsvWriterIface(mockCtrl)
 					mock := NewMockcsvHandlerIface(mockCtrl)
 					mock.EXPECT().NewWriter(gomock.Any()).Return(mockWriter).Times(1)
+					mockWriter.EXPECT().Write([]string{"#schema", "2"}).Return(nil).Times(1)
+					mockWriter.EXPECT().Write([]string{"id", "active", "balance", "tags"}).Return(nil).Times(1)
 					mockWriter.EXPECT().Write([]string{"1", "true", "1000", "[\"a\",\"b\"]"}).Return(nil).Times(1)
-					mockWriter.EXPECT().Flush().Times(1)
+					mockWriter.EXPECT().Flush().Times(2)
+					mockWriter.EXPECT().Error().Return(nil).Times(1)
 					return mock
 				}(),
 			},
@@ -100,7 +118,7 @@ func Test_storage_storeAndReplaceUserDataToCSV(t *testing.T) {
 			fields: fields{
 				fileReader: func() fReaderIface {
 					mock := NewMockfReaderIface(mockCtrl)
-					mock.EXPECT().Create("dd.csv").Return(nil, errors.New("err")).Times(1)
+					mock.EXPECT().CreateTemp(".", ".dd.csv.tmp-*", "dd.csv").Return(nil, errors.New("err")).Times(1)
 					return mock
 				}(),
 				csvHandler: func() csvHandlerIface {
@@ -126,16 +144,90 @@ func Test_storage_storeAndReplaceUserDataToCSV(t *testing.T) {
 			wantErr: true,
 			fields: fields{
 				fileReader: func() fReaderIface {
+					file := tempFile()
 					mock := NewMockfReaderIface(mockCtrl)
-					mock.EXPECT().Create("dd.csv").Return(&os.File{}, nil).Times(1)
+					mock.EXPECT().CreateTemp(".", ".dd.csv.tmp-*", "dd.csv").Return(file, nil).Times(1)
+					mock.EXPECT().Remove(file.Name()).Return(nil).Times(1)
 					return mock
 				}(),
 				csvHandler: func() csvHandlerIface {
 					mockWriter := NewMockcsvWriterIface(mockCtrl)
 					mock := NewMockcsvHandlerIface(mockCtrl)
 					mock.EXPECT().NewWriter(gomock.Any()).Return(mockWriter).Times(1)
-					mockWriter.EXPECT().Write([]string{"1", "true", "1000", "[\"a\",\"b\"]"}).Return(errors.New("err")).Times(1)
+					mockWriter.EXPECT().Write([]string{"#schema", "2"}).Return(nil).Times(1)
+					mockWriter.EXPECT().Write([]string{"id", "active", "balance", "tags"}).Return(nil).Times(1)
 					mockWriter.EXPECT().Flush().Times(1)
+					mockWriter.EXPECT().Write([]string{"1", "true", "1000", "[\"a\",\"b\"]"}).Return(errors.New("err")).Times(1)
+					return mock
+				}(),
+			},
+		},
+		{
+			name: "test4_fail_flush",
+			args: args{
+				ctx: context.Background(),
+				data: []UserData{
+					{
+						ID: "1",
+					},
+				},
+				path: "dir/dd.csv",
+			},
+			wantErr: true,
+			fields: fields{
+				fileReader: func() fReaderIface {
+					file := tempFile()
+					mock := NewMockfReaderIface(mockCtrl)
+					mock.EXPECT().CreateTemp("dir", ".dd.csv.tmp-*", "dir/dd.csv").Return(file, nil).Times(1)
+					mock.EXPECT().Remove(file.Name()).Return(nil).Times(1)
+					return mock
+				}(),
+				csvHandler: func() csvHandlerIface {
+					mockWriter := NewMockcsvWriterIface(mockCtrl)
+					mock := NewMockcsvHandlerIface(mockCtrl)
+					mock.EXPECT().NewWriter(gomock.Any()).Return(mockWriter).Times(1)
+					mockWriter.EXPECT().Write([]string{"#schema", "2"}).Return(nil).Times(1)
+					mockWriter.EXPECT().Write([]string{"id", "active", "balance", "tags"}).Return(nil).Times(1)
+					mockWriter.EXPECT().Write([]string{"1", "false", "", "null"}).Return(nil).Times(1)
+					mockWriter.EXPECT().Flush().Times(2)
+					mockWriter.EXPECT().Error().Return(errors.New("err")).Times(1)
+					return mock
+				}(),
+			},
+		},
+		{
+			name: "test5_fail_rename",
+			args: args{
+				ctx: context.Background(),
+				data: []UserData{
+					{
+						ID: "1",
+					},
+				},
+				path: "dd.csv",
+			},
+			wantErr: true,
+			fields: fields{
+				fileReader: func() fReaderIface {
+					file := tempFile()
+					mock := NewMockfReaderIface(mockCtrl)
+					index := tempFile()
+					mock.EXPECT().CreateTemp(".", ".dd.csv.tmp-*", "dd.csv").Return(file, nil).Times(1)
+					mock.EXPECT().CreateTemp(".", ".dd.csv.idx.tmp-*", "dd.csv").Return(index, nil).Times(1)
+					mock.EXPECT().Rename(index.Name(), "dd.csv.idx").Return(nil).Times(1)
+					mock.EXPECT().Rename(file.Name(), "dd.csv").Return(errors.New("err")).Times(1)
+					mock.EXPECT().Remove(file.Name()).Return(nil).Times(1)
+					return mock
+				}(),
+				csvHandler: func() csvHandlerIface {
+					mockWriter := NewMockcsvWriterIface(mockCtrl)
+					mock := NewMockcsvHandlerIface(mockCtrl)
+					mock.EXPECT().NewWriter(gomock.Any()).Return(mockWriter).Times(1)
+					mockWriter.EXPECT().Write([]string{"#schema", "2"}).Return(nil).Times(1)
+					mockWriter.EXPECT().Write([]string{"id", "active", "balance", "tags"}).Return(nil).Times(1)
+					mockWriter.EXPECT().Write([]string{"1", "false", "", "null"}).Return(nil).Times(1)
+					mockWriter.EXPECT().Flush().Times(2)
+					mockWriter.EXPECT().Error().Return(nil).Times(1)
 					return mock
 				}(),
 			},
@@ -147,14 +239,129 @@ func Test_storage_storeAndReplaceUserDataToCSV(t *testing.T) {
 				fileReader: tt.fields.fileReader,
 				csvHandler: tt.fields.csvHandler,
 			}
-			if err := s.storeAndReplaceUserDataToCSV(tt.args.ctx, tt.args.data, tt.args.path); (err != nil) != tt.wantErr {
-				t.Errorf("storage.storeAndReplaceUserDataToCSV() error = %v, wantErr %v", err, tt.wantErr)
+			if err := s.storeAndReplace(tt.args.ctx, tt.args.data, tt.args.path); (err != nil) != tt.wantErr {
+				t.Errorf("storage.storeAndReplace() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
 
-func Test_storage_searchFromCSV(t *testing.T) {
+func Test_storage_storeAndReplace_keepsPreviousFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.csv")
+	s := newStorage()
+
+	err := s.storeAndReplace(context.Background(), []UserData{{ID: "1", Tags: []string{"a"}}}, path)
+	if err != nil {
+		t.Fatalf("storage.storeAndReplace() error = %v", err)
+	}
+
+	failing := &storage{
+		fileReader: &fileHandler{},
+		csvHandler: func() csvHandlerIface {
+			mockCtrl := gomock.NewController(t)
+			mockWriter := NewMockcsvWriterIface(mockCtrl)
+			mock := NewMockcsvHandlerIface(mockCtrl)
+			mock.EXPECT().NewWriter(gomock.Any()).Return(mockWriter).Times(1)
+			mockWriter.EXPECT().Write(gomock.Any()).Return(errors.New("err")).Times(1)
+			return mock
+		}(),
+	}
+	err = failing.storeAndReplace(context.Background(), []UserData{{ID: "2"}}, path)
+	if err == nil {
+		t.Fatal("storage.storeAndReplace() expected error")
+	}
+
+	data, err := s.search(context.Background(), nil, path)
+	if err != nil {
+		t.Fatalf("storage.search() error = %v", err)
+	}
+	if want := []UserData{{ID: "1", Tags: []string{"a"}}}; !reflect.DeepEqual(data, want) {
+		t.Errorf("storage.search() = %v, want %v", data, want)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
+
+func Test_storage_storeAndReplace_mode(t *testing.T) {
+	dir := t.TempDir()
+	s := newStorage()
+	data := []UserData{{ID: "1", Tags: []string{"a"}}}
+
+	// A new file gets the mode of Create, the umask applied to 0666.
+	probe, err := os.Create(filepath.Join(dir, "probe"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	probe.Close()
+	probeInfo, err := os.Stat(probe.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mode := func(path string) os.FileMode {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.Mode().Perm()
+	}
+
+	path := filepath.Join(dir, "data.csv")
+	if err := s.storeAndReplace(context.Background(), data, path); err != nil {
+		t.Fatalf("storage.storeAndReplace() error = %v", err)
+	}
+	if got, want := mode(path), probeInfo.Mode().Perm(); got != want {
+		t.Errorf("storage.storeAndReplace() mode = %v, want %v", got, want)
+	}
+
+	// Replacing a file keeps the mode it was given.
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.storeAndReplace(context.Background(), data, path); err != nil {
+		t.Fatalf("storage.storeAndReplace() error = %v", err)
+	}
+	if got := mode(path); got != 0600 {
+		t.Errorf("storage.storeAndReplace() mode = %v, want %v", got, os.FileMode(0600))
+	}
+	if got := mode(csvIndexPath(path)); got != 0600 {
+		t.Errorf("storage.storeAndReplace() index mode = %v, want %v", got, os.FileMode(0600))
+	}
+}
+
+func Test_storage_cancelled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.csv")
+	s := newStorage()
+	want := []UserData{{ID: "1", Tags: []string{"a"}}, {ID: "2", Tags: []string{"a"}}}
+	if err := s.storeAndReplace(context.Background(), want, path); err != nil {
+		t.Fatalf("storage.storeAndReplace() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.storeAndReplace(ctx, []UserData{{ID: "3"}}, path); err != context.Canceled {
+		t.Errorf("storage.storeAndReplace() error = %v, want %v", err, context.Canceled)
+	}
+	if _, err := s.search(ctx, []string{"a"}, path); err != context.Canceled {
+		t.Errorf("storage.search() error = %v, want %v", err, context.Canceled)
+	}
+	if _, err := s.inspect(ctx, path); err != context.Canceled {
+		t.Errorf("storage.inspect() error = %v, want %v", err, context.Canceled)
+	}
+
+	// The cancelled write kept the previous file.
+	data, err := s.search(context.Background(), nil, path)
+	if err != nil || !reflect.DeepEqual(data, want) {
+		t.Errorf("storage.search() = %v, %v, want %v", data, err, want)
+	}
+}
+
+func Test_storage_search(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 
@@ -183,14 +390,17 @@ func Test_storage_searchFromCSV(t *testing.T) {
 			},
 			wantData: []UserData{
 				{
-					ID:      "1",
-					Balance: "1000",
+					ID:           "1",
+					ActiveStatus: true,
+					Balance:      "1000",
+					Tags:         []string{"a", "b"},
 				},
 			},
 			wantErr: false,
 			fields: fields{
 				fileReader: func() fReaderIface {
 					mock := NewMockfReaderIface(mockCtrl)
+					mock.EXPECT().Open("a.csv.idx").Return(nil, errors.New("err")).Times(1)
 					mock.EXPECT().Open("a.csv").Return(&os.File{}, nil).Times(1)
 					return mock
 				}(),
@@ -214,8 +424,10 @@ func Test_storage_searchFromCSV(t *testing.T) {
 			},
 			wantData: []UserData{
 				{
-					ID:      "1",
-					Balance: "1000",
+					ID:           "1",
+					ActiveStatus: true,
+					Balance:      "1000",
+					Tags:         []string{"a", "b"},
 				},
 			},
 			wantErr: false,
@@ -248,6 +460,7 @@ func Test_storage_searchFromCSV(t *testing.T) {
 			fields: fields{
 				fileReader: func() fReaderIface {
 					mock := NewMockfReaderIface(mockCtrl)
+					mock.EXPECT().Open("a.csv.idx").Return(nil, errors.New("err")).Times(1)
 					mock.EXPECT().Open("a.csv").Return(nil, errors.New("err")).Times(1)
 					return mock
 				}(),
@@ -268,6 +481,7 @@ func Test_storage_searchFromCSV(t *testing.T) {
 			fields: fields{
 				fileReader: func() fReaderIface {
 					mock := NewMockfReaderIface(mockCtrl)
+					mock.EXPECT().Open("a.csv.idx").Return(nil, errors.New("err")).Times(1)
 					mock.EXPECT().Open("a.csv").Return(&os.File{}, nil).Times(1)
 					return mock
 				}(),
@@ -291,6 +505,7 @@ func Test_storage_searchFromCSV(t *testing.T) {
 			fields: fields{
 				fileReader: func() fReaderIface {
 					mock := NewMockfReaderIface(mockCtrl)
+					mock.EXPECT().Open("a.csv.idx").Return(nil, errors.New("err")).Times(1)
 					mock.EXPECT().Open("a.csv").Return(&os.File{}, nil).Times(1)
 					return mock
 				}(),
@@ -305,6 +520,227 @@ func Test_storage_searchFromCSV(t *testing.T) {
 				}(),
 			},
 		},
+		{
+			name: "test6_fail_active_status",
+			args: args{
+				ctx:  context.Background(),
+				tags: []string{"a", "b"},
+				path: "a.csv",
+			},
+			wantErr: true,
+			fields: fields{
+				fileReader: func() fReaderIface {
+					mock := NewMockfReaderIface(mockCtrl)
+					mock.EXPECT().Open("a.csv.idx").Return(nil, errors.New("err")).Times(1)
+					mock.EXPECT().Open("a.csv").Return(&os.File{}, nil).Times(1)
+					return mock
+				}(),
+				csvHandler: func() csvHandlerIface {
+					mock := NewMockcsvHandlerIface(mockCtrl)
+					mockReader := NewMockcsvReaderIface(mockCtrl)
+					mock.EXPECT().NewReader(gomock.Any()).Return(mockReader).Times(1)
+					mockReader.EXPECT().Read().Return([]string{
+						"1", "yes", "1000", "[\"a\",\"b\"]",
+					}, nil).Times(1)
+					return mock
+				}(),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &storage{
+				fileReader: tt.fields.fileReader,
+				csvHandler: tt.fields.csvHandler,
+			}
+			gotData, err := s.search(tt.args.ctx, tt.args.tags, tt.args.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("storage.search() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(gotData, tt.wantData) {
+				t.Errorf("storage.search() = %v, want %v", gotData, tt.wantData)
+			}
+		})
+	}
+}
+
+func Test_storage_searchWithFilter(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	type fields struct {
+		fileReader fReaderIface
+		csvHandler csvHandlerIface
+	}
+	type args struct {
+		ctx    context.Context
+		query  string
+		path   string
+		filter Filter
+	}
+	tests := []struct {
+		name     string
+		fields   fields
+		args     args
+		wantData []UserData
+		wantErr  bool
+	}{
+		{
+			name: "test1_success",
+			args: args{
+				ctx:   context.Background(),
+				query: "a AND NOT c",
+				path:  "a.csv",
+			},
+			wantData: []UserData{
+				{
+					ID:           "1",
+					ActiveStatus: true,
+					Balance:      "1000",
+					Tags:         []string{"a", "b"},
+				},
+			},
+			wantErr: false,
+			fields: fields{
+				fileReader: func() fReaderIface {
+					mock := NewMockfReaderIface(mockCtrl)
+					mock.EXPECT().Open("a.csv.idx").Return(nil, errors.New("err")).Times(1)
+					mock.EXPECT().Open("a.csv").Return(&os.File{}, nil).Times(1)
+					return mock
+				}(),
+				csvHandler: func() csvHandlerIface {
+					mock := NewMockcsvHandlerIface(mockCtrl)
+					mockReader := NewMockcsvReaderIface(mockCtrl)
+					mock.EXPECT().NewReader(gomock.Any()).Return(mockReader).Times(1)
+					gomock.InOrder(
+						mockReader.EXPECT().Read().Return([]string{
+							"1", "true", "1000", "[\"a\",\"b\"]",
+						}, nil),
+						mockReader.EXPECT().Read().Return([]string{
+							"2", "true", "2000", "[\"a\",\"c\"]",
+						}, nil),
+						mockReader.EXPECT().Read().Return(nil, io.EOF),
+					)
+					return mock
+				}(),
+			},
+		},
+		{
+			name: "test2_success_active_and_balance",
+			args: args{
+				ctx:   context.Background(),
+				query: "a",
+				path:  "a.csv",
+				filter: Filter{
+					Active:     func() *bool { v := true; return &v }(),
+					MinBalance: func() *Money { v := Money(150000); return &v }(),
+				},
+			},
+			wantData: []UserData{
+				{
+					ID:           "3",
+					ActiveStatus: true,
+					Balance:      "$2,000.00",
+					Tags:         []string{"a"},
+				},
+			},
+			wantErr: false,
+			fields: fields{
+				fileReader: func() fReaderIface {
+					mock := NewMockfReaderIface(mockCtrl)
+					mock.EXPECT().Open("a.csv.idx").Return(nil, errors.New("err")).Times(1)
+					mock.EXPECT().Open("a.csv").Return(&os.File{}, nil).Times(1)
+					return mock
+				}(),
+				csvHandler: func() csvHandlerIface {
+					mock := NewMockcsvHandlerIface(mockCtrl)
+					mockReader := NewMockcsvReaderIface(mockCtrl)
+					mock.EXPECT().NewReader(gomock.Any()).Return(mockReader).Times(1)
+					gomock.InOrder(
+						mockReader.EXPECT().Read().Return([]string{
+							"1", "true", "$1,000.00", "[\"a\"]",
+						}, nil),
+						mockReader.EXPECT().Read().Return([]string{
+							"2", "false", "$2,000.00", "[\"a\"]",
+						}, nil),
+						mockReader.EXPECT().Read().Return([]string{
+							"3", "true", "$2,000.00", "[\"a\"]",
+						}, nil),
+						mockReader.EXPECT().Read().Return(nil, io.EOF),
+					)
+					return mock
+				}(),
+			},
+		},
+		{
+			name: "test3_success_skip_bad_balance",
+			args: args{
+				ctx:   context.Background(),
+				query: "a",
+				path:  "a.csv",
+				filter: Filter{
+					MaxBalance: func() *Money { v := Money(150000); return &v }(),
+				},
+			},
+			wantData: []UserData{
+				{
+					ID:           "2",
+					ActiveStatus: true,
+					Balance:      "$1,000.00",
+					Tags:         []string{"a"},
+				},
+			},
+			wantErr: false,
+			fields: fields{
+				fileReader: func() fReaderIface {
+					mock := NewMockfReaderIface(mockCtrl)
+					mock.EXPECT().Open("a.csv.idx").Return(nil, errors.New("err")).Times(1)
+					mock.EXPECT().Open("a.csv").Return(&os.File{}, nil).Times(1)
+					return mock
+				}(),
+				csvHandler: func() csvHandlerIface {
+					mock := NewMockcsvHandlerIface(mockCtrl)
+					mockReader := NewMockcsvReaderIface(mockCtrl)
+					mock.EXPECT().NewReader(gomock.Any()).Return(mockReader).Times(1)
+					gomock.InOrder(
+						mockReader.EXPECT().Read().Return([]string{
+							"1", "true", "lots", "[\"a\"]",
+						}, nil),
+						mockReader.EXPECT().Read().Return([]string{
+							"2", "true", "$1,000.00", "[\"a\"]",
+						}, nil),
+						mockReader.EXPECT().Read().Return(nil, io.EOF),
+					)
+					return mock
+				}(),
+			},
+		},
+		{
+			name: "test4_fail_bad_tags",
+			args: args{
+				ctx:   context.Background(),
+				query: "a OR b",
+				path:  "a.csv",
+			},
+			wantErr: true,
+			fields: fields{
+				fileReader: func() fReaderIface {
+					mock := NewMockfReaderIface(mockCtrl)
+					mock.EXPECT().Open("a.csv").Return(&os.File{}, nil).Times(1)
+					return mock
+				}(),
+				csvHandler: func() csvHandlerIface {
+					mock := NewMockcsvHandlerIface(mockCtrl)
+					mockReader := NewMockcsvReaderIface(mockCtrl)
+					mock.EXPECT().NewReader(gomock.Any()).Return(mockReader).Times(1)
+					mockReader.EXPECT().Read().Return([]string{
+						"1", "true", "1000", "[a",
+					}, nil).Times(1)
+					return mock
+				}(),
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -312,13 +748,152 @@ func Test_storage_searchFromCSV(t *testing.T) {
 				fileReader: tt.fields.fileReader,
 				csvHandler: tt.fields.csvHandler,
 			}
-			gotData, err := s.searchFromCSV(tt.args.ctx, tt.args.tags, tt.args.path)
+			q, err := ParseQuery(tt.args.query)
+			if err != nil {
+				t.Fatalf("ParseQuery() error = %v", err)
+			}
+			filter := tt.args.filter
+			filter.Query = q
+			gotData, err := s.searchWithFilter(tt.args.ctx, filter, tt.args.path)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("storage.searchFromCSV() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("storage.searchWithFilter() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if !reflect.DeepEqual(gotData, tt.wantData) {
-				t.Errorf("storage.searchFromCSV() = %v, want %v", gotData, tt.wantData)
+				t.Errorf("storage.searchWithFilter() = %v, want %v", gotData, tt.wantData)
+			}
+		})
+	}
+}
+
+func Test_storage_inspect(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	type fields struct {
+		fileReader fReaderIface
+		csvHandler csvHandlerIface
+	}
+	type args struct {
+		ctx  context.Context
+		path string
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		args       args
+		wantReport Report
+		wantErr    bool
+	}{
+		{
+			name: "test1_success",
+			args: args{
+				ctx:  context.Background(),
+				path: "a.csv",
+			},
+			wantReport: Report{
+				Rows:     2,
+				Active:   1,
+				Inactive: 1,
+				Tags: map[string]int{
+					"a": 2,
+					"b": 1,
+				},
+				Problems: []RowProblem{
+					{Row: 3, Err: errors.New("bad csv row format")},
+					{Row: 4, Err: errors.New("invalid active status \"yes\"")},
+					{Row: 5, Err: errors.New("duplicated id \"1\", first seen on row 1")},
+				},
+			},
+			fields: fields{
+				fileReader: func() fReaderIface {
+					mock := NewMockfReaderIface(mockCtrl)
+					mock.EXPECT().Open("a.csv").Return(&os.File{}, nil).Times(1)
+					return mock
+				}(),
+				csvHandler: func() csvHandlerIface {
+					mock := NewMockcsvHandlerIface(mockCtrl)
+					mockReader := NewMockcsvReaderIface(mockCtrl)
+					mock.EXPECT().NewReader(gomock.Any()).Return(mockReader).Times(1)
+					gomock.InOrder(
+						mockReader.EXPECT().Read().Return([]string{"1", "true", "1000", "[\"a\",\"b\"]"}, nil),
+						mockReader.EXPECT().Read().Return([]string{"2", "false", "1000", "[\"a\"]"}, nil),
+						mockReader.EXPECT().Read().Return([]string{"3", "true", "1000"}, nil),
+						mockReader.EXPECT().Read().Return([]string{"4", "yes", "1000", "[]"}, nil),
+						mockReader.EXPECT().Read().Return([]string{"1", "true", "1000", "[]"}, nil),
+						mockReader.EXPECT().Read().Return(nil, io.EOF),
+					)
+					return mock
+				}(),
+			},
+		},
+		{
+			name: "test2_fail_open",
+			args: args{
+				ctx:  context.Background(),
+				path: "a.csv",
+			},
+			wantErr: true,
+			fields: fields{
+				fileReader: func() fReaderIface {
+					mock := NewMockfReaderIface(mockCtrl)
+					mock.EXPECT().Open("a.csv").Return(nil, errors.New("err")).Times(1)
+					return mock
+				}(),
+				csvHandler: func() csvHandlerIface {
+					mock := NewMockcsvHandlerIface(mockCtrl)
+					return mock
+				}(),
+			},
+		},
+		{
+			name: "test3_fail_read",
+			args: args{
+				ctx:  context.Background(),
+				path: "a.csv",
+			},
+			wantErr: true,
+			fields: fields{
+				fileReader: func() fReaderIface {
+					mock := NewMockfReaderIface(mockCtrl)
+					mock.EXPECT().Open("a.csv").Return(&os.File{}, nil).Times(1)
+					return mock
+				}(),
+				csvHandler: func() csvHandlerIface {
+					mock := NewMockcsvHandlerIface(mockCtrl)
+					mockReader := NewMockcsvReaderIface(mockCtrl)
+					mock.EXPECT().NewReader(gomock.Any()).Return(mockReader).Times(1)
+					mockReader.EXPECT().Read().Return(nil, errors.New("err")).Times(1)
+					return mock
+				}(),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &storage{
+				fileReader: tt.fields.fileReader,
+				csvHandler: tt.fields.csvHandler,
+			}
+			gotReport, err := s.inspect(tt.args.ctx, tt.args.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("storage.inspect() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(gotReport.Tags, tt.wantReport.Tags) || gotReport.Rows != tt.wantReport.Rows ||
+				gotReport.Active != tt.wantReport.Active || gotReport.Inactive != tt.wantReport.Inactive {
+				t.Errorf("storage.inspect() = %v, want %v", gotReport, tt.wantReport)
+			}
+			if len(gotReport.Problems) != len(tt.wantReport.Problems) {
+				t.Fatalf("storage.inspect() problems = %v, want %v", gotReport.Problems, tt.wantReport.Problems)
+			}
+			for i, v := range gotReport.Problems {
+				if v.Error() != tt.wantReport.Problems[i].Error() {
+					t.Errorf("storage.inspect() problem = %v, want %v", v, tt.wantReport.Problems[i])
+				}
 			}
 		})
 	}
@@ -0,0 +1,105 @@
+package src
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// DefaultMaxBodySize is the largest source response read when
+// ClientOptions.MaxBodySize is not set.
+const DefaultMaxBodySize int64 = 256 << 20
+
+// userSeq calls yield with every user of a sequence in order, stopping at the
+// first error, which is returned. It lets users flow from a source response
+// to a storage file without collecting them in memory.
+type userSeq func(yield func(user UserData) error) error
+
+func sliceSeq(data []UserData) userSeq {
+	return func(yield func(user UserData) error) error {
+		for _, v := range data {
+			if err := yield(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// decodeUsers decodes a JSON array of users from r one item at a time, so
+// only the item being decoded is held in memory. A null array holds no users.
+func decodeUsers(r io.Reader, yield func(user UserData) error) error {
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("json: cannot unmarshal %v into a list of users", tok)
+	}
+
+	for dec.More() {
+		var user UserData
+		if err := dec.Decode(&user); err != nil {
+			return err
+		}
+		if err := yield(user); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
+
+// decodeUserSlice collects every user decodeUsers reads from r.
+func decodeUserSlice(r io.Reader) (data []UserData, err error) {
+	err = decodeUsers(r, func(user UserData) error {
+		data = append(data, user)
+		return nil
+	})
+	return data, err
+}
+
+// limitedBody fails reads past max bytes of body with ErrBodyTooLarge, unlike
+// io.LimitReader which silently truncates.
+type limitedBody struct {
+	body io.ReadCloser
+	left int64
+	max  int64
+}
+
+func limitBody(body io.ReadCloser, max int64) io.ReadCloser {
+	if max <= 0 {
+		return body
+	}
+	return &limitedBody{body: body, left: max, max: max}
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.left <= 0 {
+		// Only fail when the body really goes on past the limit.
+		var probe [1]byte
+		for {
+			n, err := l.body.Read(probe[:])
+			if n > 0 {
+				return 0, fmt.Errorf("%w: more than %d bytes", ErrBodyTooLarge, l.max)
+			}
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+	if int64(len(p)) > l.left {
+		p = p[:l.left]
+	}
+	n, err := l.body.Read(p)
+	l.left -= int64(n)
+	return n, err
+}
+
+func (l *limitedBody) Close() error {
+	return l.body.Close()
+}
@@ -0,0 +1,110 @@
+package src
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_decodeUsers(t *testing.T) {
+	errStop := errors.New("stop")
+
+	tests := []struct {
+		name      string
+		body      string
+		stopAfter int
+		want      []UserData
+		wantErr   error
+	}{
+		{
+			name: "test1_array",
+			body: `[{"_id": "1", "isActive": true, "tags": ["a"]}, {"_id": "2"}]`,
+			want: []UserData{{ID: "1", ActiveStatus: true, Tags: []string{"a"}}, {ID: "2"}},
+		},
+		{
+			name: "test2_null",
+			body: `null`,
+		},
+		{
+			name:    "test3_not_an_array",
+			body:    `{"_id": "1"}`,
+			wantErr: errors.New("json: cannot unmarshal { into a list of users"),
+		},
+		{
+			name:    "test4_truncated",
+			body:    `[{"_id": "1"}, {"_id": `,
+			want:    []UserData{{ID: "1"}},
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:      "test5_stopped_by_yield",
+			body:      `[{"_id": "1"}, {"_id": "2"}, {"_id": "3"}]`,
+			stopAfter: 2,
+			want:      []UserData{{ID: "1"}, {ID: "2"}},
+			wantErr:   errStop,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []UserData
+			err := decodeUsers(strings.NewReader(tt.body), func(user UserData) error {
+				got = append(got, user)
+				if len(got) == tt.stopAfter {
+					return errStop
+				}
+				return nil
+			})
+			if (err == nil) != (tt.wantErr == nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
+				t.Errorf("decodeUsers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeUsers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_limitBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		max     int64
+		wantErr bool
+	}{
+		{
+			name: "test1_no_limit",
+			body: "0123456789",
+		},
+		{
+			name: "test2_exactly_max",
+			body: "0123456789",
+			max:  10,
+		},
+		{
+			name:    "test3_over_max",
+			body:    "0123456789",
+			max:     9,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ioutil.ReadAll(limitBody(ioutil.NopCloser(strings.NewReader(tt.body)), tt.max))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("limitBody() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrBodyTooLarge) {
+					t.Errorf("limitBody() error = %v, want %v", err, ErrBodyTooLarge)
+				}
+				return
+			}
+			if string(got) != tt.body {
+				t.Errorf("limitBody() = %q, want %q", got, tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package src
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TombstonePolicy decides what an upsert does with stored users whose ID is
+// missing from the new data.
+type TombstonePolicy int
+
+const (
+	// TombstoneKeep leaves missing users untouched, for partial fetches.
+	TombstoneKeep TombstonePolicy = iota
+	// TombstoneDelete removes missing users.
+	TombstoneDelete
+	// TombstoneDeactivate keeps missing users with ActiveStatus false.
+	TombstoneDeactivate
+)
+
+func (p TombstonePolicy) String() string {
+	switch p {
+	case TombstoneKeep:
+		return "keep"
+	case TombstoneDelete:
+		return "delete"
+	case TombstoneDeactivate:
+		return "deactivate"
+	}
+	return fmt.Sprintf("TombstonePolicy(%d)", int(p))
+}
+
+// ParseTombstonePolicy converts "keep", "delete" or "deactivate" into a
+// TombstonePolicy.
+func ParseTombstonePolicy(s string) (TombstonePolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "keep":
+		return TombstoneKeep, nil
+	case "delete":
+		return TombstoneDelete, nil
+	case "deactivate":
+		return TombstoneDeactivate, nil
+	}
+	return 0, fmt.Errorf("unknown tombstone policy %q", s)
+}
+
+// UpsertResult counts what an upsert changed in the stored users.
+type UpsertResult struct {
+	Added       int
+	Updated     int
+	Unchanged   int
+	Deleted     int
+	Deactivated int
+}
+
+// upsertUserData merges data into the stored users keyed by ID. Stored users
+// keep their position and are replaced by the new record with the same ID,
+// new IDs are appended in the order of data. When data repeats an ID the last
+// record wins. Stored users missing from data are handled by policy.
+func upsertUserData(stored, data []UserData, policy TombstonePolicy) (merged []UserData, result UpsertResult) {
+	latest := make(map[string]UserData, len(data))
+	for _, v := range data {
+		latest[v.ID] = v
+	}
+
+	merged = make([]UserData, 0, len(stored)+len(data))
+	seen := make(map[string]struct{}, len(stored))
+	for _, v := range stored {
+		seen[v.ID] = struct{}{}
+		update, ok := latest[v.ID]
+		switch {
+		case ok && sameUser(v, update):
+			result.Unchanged++
+		case ok:
+			result.Updated++
+			v = update
+		case policy == TombstoneDelete:
+			result.Deleted++
+			continue
+		case policy == TombstoneDeactivate && v.ActiveStatus:
+			result.Deactivated++
+			v.ActiveStatus = false
+		default:
+			result.Unchanged++
+		}
+		merged = append(merged, v)
+	}
+
+	for _, v := range data {
+		if _, ok := seen[v.ID]; ok {
+			continue
+		}
+		seen[v.ID] = struct{}{}
+		result.Added++
+		merged = append(merged, latest[v.ID])
+	}
+
+	return merged, result
+}
+
+// sameUser reports whether a and b hold the same data. nil and empty tags
+// are equal, as the storage formats do not tell them apart.
+func sameUser(a, b UserData) bool {
+	return a.ID == b.ID &&
+		a.ActiveStatus == b.ActiveStatus &&
+		a.Balance == b.Balance &&
+		equalTags(a.Tags, b.Tags)
+}
+
+func equalTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,150 @@
+package src
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseTombstonePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    TombstonePolicy
+		wantErr bool
+	}{
+		{
+			name: "test1_keep",
+			s:    "keep",
+			want: TombstoneKeep,
+		},
+		{
+			name: "test2_delete",
+			s:    " Delete ",
+			want: TombstoneDelete,
+		},
+		{
+			name: "test3_deactivate",
+			s:    "deactivate",
+			want: TombstoneDeactivate,
+		},
+		{
+			name:    "test4_unknown",
+			s:       "purge",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTombstonePolicy(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseTombstonePolicy() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseTombstonePolicy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_upsertUserData(t *testing.T) {
+	stored := []UserData{
+		{ID: "1", ActiveStatus: true, Balance: "$1.00"},
+		{ID: "2", ActiveStatus: true, Balance: "$2.00"},
+		{ID: "3", ActiveStatus: true, Balance: "$3.00"},
+		{ID: "4", Balance: "$4.00"},
+		{ID: "6", Balance: "$6.00", Tags: []string{}},
+	}
+	data := []UserData{
+		{ID: "5", Balance: "$5.00"},
+		{ID: "2", ActiveStatus: true, Balance: "$2.00", Tags: []string{}},
+		{ID: "1", ActiveStatus: true, Balance: "$10.00"},
+		{ID: "5", Balance: "$50.00"},
+		{ID: "6", Balance: "$6.00"},
+	}
+
+	tests := []struct {
+		name       string
+		policy     TombstonePolicy
+		want       []UserData
+		wantResult UpsertResult
+	}{
+		{
+			name:   "test1_keep",
+			policy: TombstoneKeep,
+			want: []UserData{
+				{ID: "1", ActiveStatus: true, Balance: "$10.00"},
+				{ID: "2", ActiveStatus: true, Balance: "$2.00"},
+				{ID: "3", ActiveStatus: true, Balance: "$3.00"},
+				{ID: "4", Balance: "$4.00"},
+				{ID: "6", Balance: "$6.00", Tags: []string{}},
+				{ID: "5", Balance: "$50.00"},
+			},
+			wantResult: UpsertResult{Added: 1, Updated: 1, Unchanged: 4},
+		},
+		{
+			name:   "test2_delete",
+			policy: TombstoneDelete,
+			want: []UserData{
+				{ID: "1", ActiveStatus: true, Balance: "$10.00"},
+				{ID: "2", ActiveStatus: true, Balance: "$2.00"},
+				{ID: "6", Balance: "$6.00", Tags: []string{}},
+				{ID: "5", Balance: "$50.00"},
+			},
+			wantResult: UpsertResult{Added: 1, Updated: 1, Unchanged: 2, Deleted: 2},
+		},
+		{
+			name:   "test3_deactivate",
+			policy: TombstoneDeactivate,
+			want: []UserData{
+				{ID: "1", ActiveStatus: true, Balance: "$10.00"},
+				{ID: "2", ActiveStatus: true, Balance: "$2.00"},
+				{ID: "3", Balance: "$3.00"},
+				{ID: "4", Balance: "$4.00"},
+				{ID: "6", Balance: "$6.00", Tags: []string{}},
+				{ID: "5", Balance: "$50.00"},
+			},
+			wantResult: UpsertResult{Added: 1, Updated: 1, Unchanged: 3, Deactivated: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotResult := upsertUserData(stored, data, tt.policy)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("upsertUserData() = %v, want %v", got, tt.want)
+			}
+			if gotResult != tt.wantResult {
+				t.Errorf("upsertUserData() result = %+v, want %+v", gotResult, tt.wantResult)
+			}
+		})
+	}
+}
+
+func Test_storage_upsert(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.csv")
+	s := newStorage()
+
+	first := []UserData{{ID: "1", Balance: "$1.00", Tags: []string{"a"}}}
+	result, err := s.upsert(context.Background(), first, path, TombstoneKeep)
+	if err != nil {
+		t.Fatalf("storage.upsert() error = %v", err)
+	}
+	if result != (UpsertResult{Added: 1}) {
+		t.Errorf("storage.upsert() = %+v, want one added", result)
+	}
+
+	second := []UserData{{ID: "2", Balance: "$2.00", Tags: []string{"a"}}}
+	if _, err := s.upsert(context.Background(), second, path, TombstoneKeep); err != nil {
+		t.Fatalf("storage.upsert() error = %v", err)
+	}
+
+	got, err := s.search(context.Background(), []string{"a"}, path)
+	if err != nil {
+		t.Fatalf("storage.search() error = %v", err)
+	}
+	if want := append(first, second...); !reflect.DeepEqual(got, want) {
+		t.Errorf("storage.search() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,62 @@
+package ccli
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+func runStats(args []string) error {
+	var (
+		fs      = newFlagSet("stats", "Print row counts and the most used tags of the data file.")
+		path    = fs.String("path", defaultPath, "data file to summarize")
+		top     = fs.Int("top", 10, "number of tags to list, 0 lists all of them")
+		timeout time.Duration
+		store   string
+	)
+	registerStore(fs, &store)
+	registerTimeout(fs, &timeout)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext(timeout)
+	defer cancel()
+
+	client, err := build(store)
+	if err != nil {
+		return usageError(err)
+	}
+	report, err := client.Inspect(ctx, *path)
+	if err != nil {
+		return storageError(err)
+	}
+
+	fmt.Printf("Schema: %d\n", report.Version)
+	fmt.Printf("Users: %d\n", report.Rows)
+	fmt.Printf("Active: %d\n", report.Active)
+	fmt.Printf("Inactive: %d\n", report.Inactive)
+	if len(report.Problems) > 0 {
+		fmt.Printf("Invalid rows: %d (see 'ccli validate')\n", len(report.Problems))
+	}
+
+	tags := make([]string, 0, len(report.Tags))
+	for k := range report.Tags {
+		tags = append(tags, k)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if report.Tags[tags[i]] != report.Tags[tags[j]] {
+			return report.Tags[tags[i]] > report.Tags[tags[j]]
+		}
+		return tags[i] < tags[j]
+	})
+	if *top > 0 && len(tags) > *top {
+		tags = tags[:*top]
+	}
+
+	fmt.Printf("Tags: %d distinct\n", len(report.Tags))
+	for _, v := range tags {
+		fmt.Printf("  %s: %d\n", v, report.Tags[v])
+	}
+	return nil
+}
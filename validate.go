@@ -0,0 +1,47 @@
+package ccli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rizaldihuzein/ccli/src"
+)
+
+func runValidate(args []string) error {
+	var (
+		fs      = newFlagSet("validate", "Check that every row of the data file can be read back and that IDs are unique.")
+		path    = fs.String("path", defaultPath, "data file to validate")
+		timeout time.Duration
+		store   string
+	)
+	registerStore(fs, &store)
+	registerTimeout(fs, &timeout)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext(timeout)
+	defer cancel()
+
+	client, err := build(store)
+	if err != nil {
+		return usageError(err)
+	}
+	report, err := client.Inspect(ctx, *path)
+	if err != nil {
+		return storageError(err)
+	}
+
+	if report.Version < src.SchemaVersion {
+		fmt.Printf("%s: schema version %d is outdated, run 'ccli migrate'\n", *path, report.Version)
+	}
+	for _, v := range report.Problems {
+		fmt.Println(v.Error())
+	}
+	if len(report.Problems) > 0 {
+		fmt.Printf("%s: %d valid rows, %d invalid rows\n", *path, report.Rows, len(report.Problems))
+		return storageError(fmt.Errorf("%s has %d invalid rows", *path, len(report.Problems)))
+	}
+	fmt.Printf("%s: all %d rows are valid\n", *path, report.Rows)
+	return nil
+}